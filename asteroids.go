@@ -10,16 +10,18 @@ Add music
 
 import (
 	"embed"
+	"flag"
+	"fmt"
+	"math/rand/v2"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 const (
 	ScreenWidth  = 1000
 	ScreenHeight = 800
-	StartSpawnTime = 3			// New Meteor created every 3 seconds at start
-	SpawnChangeTime = 0.10		// Gap between new meteors reduced by this every SpawnChangeInterval
-	MinSpawnTime = 2			// Minimum gap between new meteors being created
-	SpawnChangeInterval = 120   // spawn time changed every 2 minutes
+	StartSpawnTime = 3			// Default gap between extra meteors arriving during a wave
+	SpawnClearance = 120		// Distance to the nearest meteor or saucer before a player comes back
 )
 
 // Game mode
@@ -27,124 +29,553 @@ const (
 	Inst = 0
 	InPlay = 1
 	GameOver = 2
+	Controls = 3
+	Paused = 4
+	RoundOver = 5
+	Network = 6
 )
 
+var seedFlag = flag.Uint64("seed", 0, "random seed used for every game (0 for a new seed each game)")
+
 // Embeds all of asset resources to assets
 //go:embed assets/*
 var assets embed.FS
 
 type Game struct{
-	spawnSpeed			float64
+	world				*World
 	spawnTimer 			*Timer
-	spawnUpdateTimer	*Timer
-	playerHitTimer		*Timer
-	player 				*Player
+	waveTimer			*Timer		// Started when a wave is cleared
+	saucerTimer			*Timer
+	players				[] *Player
 	scoreboard 			*ScoreBoard
+	inputs				[] InputSource	// Input for each player in the game in play
+	inputStates			[] InputState	// Reused for the input read each tick
+	menuInput			InputSource		// Input for menus and pausing, not recorded
+	lastMenu			InputState		// Menu input from the last tick to find key presses
+	pauseMenu			*PauseMenu
+	controlsReturn		int				// Mode to return to from the controls screen
+	bindings			*Bindings		// Controls shown on screen
+	coopBindings		[MaxPlayers] *Bindings	// Controls shown on screen for two players
+	controls			*ControlsScreen	// Set when the controls can be changed
+	gamepad				Gamepad			// Set when a gamepad can be used
+	seed				uint64	// 0 to choose a new random seed for each game
+	recorder			*Recorder	// Set when recording games
+	replay				*Replay		// Set when playing back a recording
+	net					*NetSession		// Set while playing over the network
+	offline				*OfflineSetup	// Set up to restore when the network game is left
+	attract				*OfflineSetup	// Set up to restore when the autopilot stops playing
+	idleTicks			int			// Ticks on the title screen with no input
+	lobby				*Lobby
+	spectators			*SpectatorServer	// Set when the game can be watched
+	ticks				int			// Number of updates since the game started
+	waves				[] WaveSpec
+	wave				int			// Number of the wave in play counting from 1
+	round				int			// Number of the round in play in versus counting from 1
+	roundOverTicks		int			// Ticks left showing the scores between rounds
+	extraMeteors		int			// Meteors still to arrive during the wave
+	waveCleared			bool
+	bannerTicks			int			// Ticks left to show the wave number
+	showShapes			bool		// Draw the collision shapes for debugging
 	game_mode			int
 }
 
 func (g *Game) Update() error {
-	UpdateStars()	// Background
-	if g.game_mode == InPlay {
-		UpdateAllTimers()
-		ClearDoneExplosions()
-		ClearDoneMeteors()
-		ClearDoneMissiles()
-		if g.spawnTimer.IsReady() {
-			NewMeteor(g.player)
-		}
-		if g.spawnUpdateTimer.IsReady() {
-			if g.spawnSpeed > MinSpawnTime {
-				g.spawnSpeed -= SpawnChangeTime
-				g.spawnTimer.ChangeTime(g.spawnSpeed, true)
+	// Menu input is read every tick but is not recorded so pausing
+	// and menus never change a recorded game
+	var menu InputState
+	if g.menuInput != nil {
+		menu = g.menuInput.Read()
+	}
+	last := g.lastMenu
+	pressed := func(a Action) bool {
+		return menu.Held(a) && !last.Held(a)
+	}
+	anyPressed := menu.actions != last.actions && menu.actions != [NumActions] bool{}
+	if menu != last {
+		g.idleTicks = 0
+	}
+	g.lastMenu = menu
+	if g.menuInput != nil {
+		g.UpdateShowShapes()
+	}
+
+	if !g.Frozen() {
+		g.world.UpdateStars()	// Background
+	}
+	switch g.game_mode {
+	case InPlay, RoundOver:
+		if g.attract != nil && anyPressed {
+			g.StopAttract()
+			g.Start()
+			break
+		}
+		if g.game_mode == RoundOver {
+			if g.NetReady() {
+				g.UpdateRoundOver(g.ReadInputs())
+				g.NetAdvance()
 			}
+			break
 		}
-		if g.player.alive {
-			g.player.Update()
-		}
-		UpdateAllMeteors()
-		UpdateAllMissiles()
-		for _, miss := range missiles {
-			// Check if hit a meteor
-			missPos := miss.ScreenPos()
-			for _, met := range meteors {
-				if missPos.Collides(met.ScreenPos()){
-					// mark as hit, update score and set as done so removed next frame
-					g.scoreboard.score += met.Hit(true)
-					miss.done = true
-					break
-				}
+		if g.attract != nil {
+			// The autopilot keeps playing while the window is in the background
+			g.UpdatePlay(g.ReadInputs())
+		} else if g.net != nil && g.menuInput != nil && pressed(ActionPause) {
+			// A network game cannot be paused as the other player would have to wait
+			g.LeaveNetGame()
+		} else if g.net == nil && g.menuInput != nil && (pressed(ActionPause) || !ebiten.IsFocused()) {
+			g.Pause()
+		} else if g.NetReady() {
+			g.UpdatePlay(g.ReadInputs())
+			g.NetAdvance()
+		}
+	case Paused:
+		g.UpdatePauseMenu(pressed)
+	case Network:
+		g.UpdateLobby()
+	case Controls:
+		if g.controls.Update() {
+			g.game_mode = g.controlsReturn
+		}
+	default:
+		// Check if a key has been pressed
+		if g.net != nil {
+			// Only the host starts another network game
+			if pressed(ActionPause) {
+				g.LeaveNetGame()
+			} else {
+				g.UpdateNetStart(pressed(ActionStart))
+			}
+		} else if pressed(ActionStart) {
+			g.Start()
+		} else if g.game_mode == Inst && g.controls != nil && g.world.options.Players() == 1 &&
+			inpututil.IsKeyJustPressed(ebiten.KeyC) {
+			g.controlsReturn = Inst
+			g.game_mode = Controls
+		} else if g.game_mode == Inst && g.lobby != nil && inpututil.IsKeyJustPressed(ebiten.KeyN) {
+			g.game_mode = Network
+		} else if g.game_mode == Inst && g.menuInput != nil {
+			g.idleTicks++
+			if g.idleTicks >= AttractIdleTime * ebiten.TPS() || inpututil.IsKeyJustPressed(ebiten.KeyA) {
+				g.StartAttract()
 			}
 		}
-		if g.player.alive {
-			playerPos := g.player.ScreenPos()
-			for _, met := range(meteors){
-				// Check if hit player
-				if playerPos.Collides(met.ScreenPos()){
-					g.player.Hit()
-					g.scoreboard.lives -= 1
-					g.playerHitTimer = NewTimer(3, false)
-					g.spawnTimer.active = false
-					met.Hit(false)
+	}
+	g.CheckReplayStopped()
+	if g.spectators != nil && g.spectators.Watched() {
+		g.spectators.Broadcast(g.Snapshot())
+	}
+	return nil
+}
+
+// Check if the game can be played for a tick, in a network game
+// it waits for the input of both players to arrive
+func (g *Game) NetReady() bool {
+	return g.net == nil || g.net.Poll()
+}
+
+// Move the network game on to the next tick once one has been played
+func (g *Game) NetAdvance() {
+	if g.net != nil {
+		g.net.Advance(g.world)
+	}
+}
+
+// Read the input of every player for this tick
+// The slice returned is only valid until the next call
+func (g *Game) ReadInputs() [] InputState {
+	g.inputStates = g.inputStates[:0]
+	for i := range g.players {
+		var in InputState
+		if i < len(g.inputs) {
+			in = g.inputs[i].Read()
+		}
+		g.inputStates = append(g.inputStates, in)
+	}
+	return g.inputStates
+}
+
+// Advance the game in play by one tick using the input given for each player
+func (g *Game) UpdatePlay(inputs [] InputState) {
+	g.ticks++
+	g.world.Update()
+	g.UpdateWave()
+	if g.saucerTimer.IsReady() && g.world.PlayersAlive() > 0 && len(g.world.saucers) == 0 {
+		NewSaucer(g.world, SaucerSize(g.world, TotalScore(g.players)))
+	}
+	for i, p := range g.players {
+		if p.alive {
+			p.Update(inputs[i])
+		}
+	}
+	g.world.Move()
+	g.world.BuildMeteorGrid()
+	if g.world.options.meteorCollide {
+		g.world.CollideMeteors()
+	}
+	for _, miss := range g.world.missiles {
+		// Check if hit a meteor
+		for _, met := range g.world.MeteorsNear(miss.Bounds()) {
+			if g.world.MissileHits(miss, &met.GameSprite){
+				// mark as hit, update the owner's score and set as done so removed
+				// next frame unless it pierces
+				miss.owner.score += miss.HitMeteor(met)
+				if miss.done {
 					break
 				}
 			}
-		} else {
-			if g.playerHitTimer.IsReady() {
-				if g.scoreboard.lives == 0 {
-					g.game_mode = GameOver
-					g.spawnTimer.active = false
-					g.spawnUpdateTimer.active = false
-				} else {
-					g.player.Reset()
-					ClearAllMeteors()
-					ClearAllMissiles()
-					ClearAllExplosions()
-					g.spawnTimer.Reset()
- 		   			// create a single meteor to start
-					NewMeteor(g.player)
+		}
+		// Check if hit a saucer, there is at most one so it is not in a grid
+		for _, s := range g.world.saucers {
+			if g.world.MissileHits(miss, &s.GameSprite){
+				miss.owner.score += s.Hit(true)
+				miss.done = !miss.weapon.pierce
+				break
+			}
+		}
+	}
+	// Saucers and their shots are destroyed by meteors they fly into
+	for _, s := range g.world.saucers {
+		for _, met := range g.world.MeteorsNear(s.Bounds()) {
+			if g.world.Collides(&s.GameSprite, &met.GameSprite){
+				s.Hit(true)
+				met.Hit(true)
+				break
+			}
+		}
+	}
+	for _, shot := range g.world.saucerShots {
+		for _, met := range g.world.MeteorsNear(shot.Bounds()) {
+			if g.world.Collides(&shot.GameSprite, &met.GameSprite){
+				shot.done = true
+				met.Hit(true)
+				break
+			}
+		}
+	}
+	for _, p := range g.players {
+		if p.alive {
+			g.CheckPlayerHit(p)
+		}
+	}
+	if g.world.options.versus {
+		g.UpdateRound()
+	} else {
+		g.UpdateRespawn()
+	}
+	g.world.UpdateAllExplosions()
+}
+
+// Check if the player has flown into a meteor or saucer or been shot
+// With a shield anything the player touches is destroyed instead and
+// with the deflector up meteors bounce off
+// Only meteors are found with the grid, the rest are too few to need one
+func (g *Game) CheckPlayerHit(player *Player) {
+	deflecting := g.Deflect(player)
+	shield := player.HasEffect(EffectShield) || deflecting
+	if !deflecting {
+		for _, met := range(g.world.MeteorsNear(player.Bounds())){
+			if g.world.Collides(&player.GameSprite, &met.GameSprite){
+				if shield {
+					player.score += met.Hit(true)
+					continue
 				}
+				g.PlayerHit(player, nil)
+				met.Hit(false)
+				return
 			}
 		}
-		UpdateAllExplosions()
+	}
+	for _, s := range g.world.saucers {
+		if g.world.Collides(&player.GameSprite, &s.GameSprite){
+			player.score += s.Hit(true)
+			if !shield {
+				g.PlayerHit(player, nil)
+				return
+			}
+		}
+	}
+	for _, shot := range g.world.saucerShots {
+		if g.world.Collides(&player.GameSprite, &shot.GameSprite){
+			shot.done = true
+			if !shield {
+				g.PlayerHit(player, nil)
+				return
+			}
+		}
+	}
+	if g.world.options.versus && g.CheckShot(player, shield) {
+		return
+	}
+	for _, p := range g.world.pickups {
+		if g.world.Collides(&player.GameSprite, &p.GameSprite){
+			g.Collect(p, player)
+		}
+	}
+}
+
+// Lose a life and stop more meteors arriving once nobody is left flying
+// killer is the player whose missile hit the ship in versus, where it
+// scores a kill and lives are not used, or nil for anything else
+func (g *Game) PlayerHit(player, killer *Player) {
+	player.Hit()
+	player.killedBy = killer
+	if killer != nil {
+		killer.kills += 1
+	}
+	if !g.world.options.versus {
+		player.lives -= 1
+	}
+	if g.world.PlayersAlive() == 0 {
+		g.spawnTimer.Stop()
+		g.waveTimer.Stop()
+	}
+}
+
+// Bring back players who have been hit once their time is up
+// While anyone is still flying a player comes back at its start as soon as
+// nothing is close. Once everyone is down the wave starts again, or the next
+// if it had been cleared, and the game ends when nobody has a life left.
+func (g *Game) UpdateRespawn() {
+	if g.world.PlayersAlive() > 0 {
+		for _, p := range g.players {
+			if !p.alive && p.lives > 0 && g.SpawnClear(p) && p.respawnTimer.IsReady() {
+				p.Reset()
+			}
+		}
+		return
+	}
+	lives := 0
+	for _, p := range g.players {
+		if p.respawnTimer.Running() {
+			return
+		}
+		lives += p.lives
+	}
+	if lives == 0 {
+		g.End()
+		return
+	}
+	for _, p := range g.players {
+		p.respawnTimer.Stop()
+		if p.lives > 0 {
+			p.Reset()
+		}
+	}
+	// Start the wave again or the next if it had been cleared
+	if g.waveCleared {
+		g.StartWave(g.wave + 1)
 	} else {
-		// Check if a key has been pressed
-		if ebiten.IsKeyPressed(ebiten.KeySpace) {
-			// Stop player firing for reload period ans set for new game
-			g.player.Reset()
-			g.player.loaded = false
-			reloadTimer = reloadTime
-			ClearAllMeteors()
-			ClearAllMissiles()
-			ClearAllExplosions()
-			g.scoreboard.score = 0
-			g.scoreboard.lives = 3
-			g.spawnSpeed = StartSpawnTime
-			g.spawnTimer.ChangeTime(StartSpawnTime, true)
-			g.spawnUpdateTimer.Reset()
-			g.scoreboard.LoadHighScore()
-			g.scoreboard.highScoreSaved = false
-   			// create a single meteor to start
-			NewMeteor(g.player)
-			g.game_mode = InPlay
+		g.StartWave(g.wave)
+	}
+}
+
+// Check nothing is close to where the player starts so it is not hit as soon as it is back
+func (g *Game) SpawnClear(p *Player) bool {
+	start := p.StartPosition()
+	size := SpawnClearance * 2
+	for _, met := range g.world.MeteorsNear(NewScreenPos(start.X, start.Y, size, size)) {
+		if g.world.CollidesCircle(start, SpawnClearance, &met.GameSprite) {
+			return false
 		}
 	}
-	return nil
+	for _, s := range g.world.saucers {
+		if g.world.CollidesCircle(start, SpawnClearance, &s.GameSprite) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check if the game is in play, including while paused or between rounds
+func (g *Game) Playing() bool {
+	return g.game_mode == InPlay || g.game_mode == Paused || g.game_mode == RoundOver ||
+		(g.game_mode == Controls && g.controlsReturn == Paused)
+}
+
+// Check if everything, including the background, should stay still
+func (g *Game) Frozen() bool {
+	return g.Playing() && g.game_mode != InPlay
+}
+
+// Use the bindings given for the keyboard and allow them to be changed
+func (g *Game) SetBindings(b *Bindings) {
+	g.bindings = b
+	g.controls = NewControlsScreen(b)
+}
+
+// Set up and start a new game
+func (g *Game) Start() {
+	seed := g.seed
+	if g.net != nil && !g.net.host {
+		// The host chooses the seed
+		seed = g.net.seed
+	} else if seed == 0 {
+		seed = rand.Uint64()
+	}
+	if g.net != nil {
+		g.net.Begin(seed, g.world.options, g.waves)
+	}
+	g.world.SetSeed(seed)
+	g.scoreboard.seed = seed
+	if g.recorder != nil {
+		g.recorder.Begin(g.world.options, g.waves, seed)
+	}
+	g.ticks = 0
+	g.world.timers.Resume()
+	// Stop players firing for reload period ans set for new game
+	for _, p := range g.players {
+		p.Reset()
+		p.ResetWeapons()
+		p.loaded = false
+		p.reloadTimer = reloadTime
+		p.score = 0
+		p.lives = StartLives
+		p.kills = 0
+		p.respawnTimer.Stop()
+	}
+	g.saucerTimer.ChangeTime(SaucerTime, true)
+	g.scoreboard.LoadHighScore()
+	g.scoreboard.highScoreSaved = false
+	g.round = 0
+	if g.world.options.versus {
+		g.StartRound()
+	} else {
+		g.StartWave(1)
+	}
+	g.game_mode = InPlay
+}
+
+// Stop the game timers and save any recording, ended is false
+// if the game is being abandoned before it ended
+func (g *Game) stop(ended bool) {
+	g.spawnTimer.Stop()
+	g.waveTimer.Stop()
+	g.saucerTimer.Stop()
+	g.world.timers.Resume()
+	if g.recorder != nil {
+		g.recorder.Finish(TotalScore(g.players), ended)
+	}
+}
+
+// Abandon the game and return to the title screen
+func (g *Game) Quit() {
+	g.stop(false)
+	g.game_mode = Inst
+}
+
+// Watch the autopilot play a game, attract mode
+func (g *Game) StartAttract() {
+	g.attract = &OfflineSetup{inputs: g.inputs, recorder: g.recorder}
+	// The autopilot's games are not recorded
+	g.recorder = nil
+	g.inputs = NewBots(g)
+	g.Start()
+}
+
+// Stop the autopilot and go back to the title screen
+func (g *Game) StopAttract() {
+	g.stop(false)
+	g.inputs = g.attract.inputs
+	g.recorder = g.attract.recorder
+	g.attract = nil
+	g.idleTicks = 0
+	g.game_mode = Inst
+}
+
+// Finish the game once the last life of every player has gone
+func (g *Game) End() {
+	if g.attract != nil {
+		// The autopilot's score is not a high score
+		g.StopAttract()
+		return
+	}
+	g.stop(true)
+	g.game_mode = GameOver
+	g.VerifyReplay(true)
+}
+
+// Stop playing back a replay of a game that was abandoned once its
+// recorded input runs out, as the game was quit there
+func (g *Game) CheckReplayStopped() {
+	if g.replay != nil && !g.replay.ended && g.ticks >= g.replay.Ticks() &&
+		(g.game_mode == InPlay || g.game_mode == RoundOver) {
+		g.Quit()
+		g.VerifyReplay(false)
+	}
+}
+
+// Report whether the replay played back as it was recorded
+func (g *Game) VerifyReplay(ended bool) {
+	if g.replay == nil {
+		return
+	}
+	if err := g.replay.Verify(g.ticks, TotalScore(g.players), ended); err != nil {
+		fmt.Println("Replay does not match:", err)
+	} else {
+		fmt.Println("Replay matches the recording")
+	}
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	DrawStars(screen)
+	g.world.DrawStars(screen)
 	switch g.game_mode {
-	case InPlay:
-		g.player.Draw(screen)
-		DrawAllMeteors(screen)
-		DrawAllMissiles(screen)
-		DrawAllExplosions(screen)
-		g.scoreboard.DrawScore(screen)
+	case InPlay, Paused:
+		for _, p := range g.players {
+			p.Draw(screen)
+			p.DrawEffects(screen)
+			p.DrawDeflector(screen)
+		}
+		g.world.Draw(screen)
+		if g.showShapes {
+			for _, p := range g.players {
+				if p.alive {
+					g.world.placedA = p.DrawShape(screen, g.world.placedA)
+				}
+			}
+			g.world.DrawShapes(screen)
+		}
+		g.scoreboard.DrawScore(screen, g.players)
+		if g.bannerTicks > 0 {
+			g.scoreboard.DrawWaveBanner(screen)
+		}
+		if g.game_mode == Paused {
+			g.scoreboard.DrawPauseMenu(screen, g.pauseMenu, g.bindings)
+		}
+		if g.attract != nil {
+			g.scoreboard.DrawCenter(screen, "Autopilot playing, press fire to play", ScreenWidth/2, ScreenHeight - 40, 20, aqua)
+		}
+	case RoundOver:
+		for _, p := range g.players {
+			p.Draw(screen)
+		}
+		g.world.Draw(screen)
+		g.scoreboard.DrawScore(screen, g.players)
+		g.scoreboard.DrawRoundOver(screen, g.players, g.round)
+		if g.attract != nil {
+			g.scoreboard.DrawCenter(screen, "Autopilot playing, press fire to play", ScreenWidth/2, ScreenHeight - 40, 20, aqua)
+		}
 	case GameOver:
-		g.scoreboard.DrawGameOver(screen)
+		if g.world.options.versus {
+			g.scoreboard.DrawWinner(screen, g.bindings, g.players, g.round)
+		} else {
+			g.scoreboard.DrawGameOver(screen, g.bindings, g.players)
+		}
+	case Controls:
+		g.scoreboard.DrawControls(screen, g.controls)
+	case Network:
+		g.scoreboard.DrawLobby(screen, g.lobby, g.net)
 	default:
-		g.scoreboard.DrawInstructions(screen)
+		if g.world.options.Players() > 1 {
+			g.scoreboard.DrawTwoPlayerInstructions(screen, g.bindings, g.coopBindings, g.world.options.versus)
+		} else {
+			g.scoreboard.DrawInstructions(screen, g.bindings, g.gamepad != nil && g.gamepad.Connected())
+		}
+		if g.lobby != nil {
+			g.scoreboard.DrawCenter(screen, "Press N to play co-op over a network or A to watch the autopilot", ScreenWidth/2, 775, 18, white)
+		}
+	}
+	if g.net != nil && g.game_mode != Network {
+		g.scoreboard.DrawNetStatus(screen, g.net, g.game_mode == InPlay || g.game_mode == RoundOver)
 	}
 }
 
@@ -152,20 +583,96 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeigh
 	return outsideWidth, outsideHeight
 }
 
-func main() {
+// Create a new game with its own world reading the input for each player from inputs
+// Every game is played with the options and seed given or a random seed if seed is 0
+func NewGame(inputs [] InputSource, options Options, seed uint64) *Game {
+	w := NewWorld(options, rand.Uint64())
 	g := &Game{
-		spawnSpeed: StartSpawnTime,
-		spawnTimer: NewTimer(StartSpawnTime, true),
-		spawnUpdateTimer: NewTimer(SpawnChangeInterval, true),
-		player: NewPlayer(),
+		world: w,
+		spawnTimer: NewTimer(w, StartSpawnTime, true),
+		waveTimer: NewTimer(w, WaveGap, false),
+		saucerTimer: NewTimer(w, SaucerTime, true),
 		scoreboard: NewScoreBoard(),
+		pauseMenu: &PauseMenu{},
+		inputs: inputs,
+		bindings: DefaultBindings(),
+		coopBindings: CoopBindings(),
+		waves: LoadWaves(options.waves),
+		seed: seed,
+		showShapes: *showShapesFlag,
 		game_mode: Inst,
 	}
+	for i := 0; i < options.Players(); i++ {
+		g.players = append(g.players, NewPlayer(w))
+	}
+	g.scoreboard.versus = options.versus
+	g.scoreboard.killsToWin = options.kills
+	// Only started when a wave is cleared
+	g.waveTimer.Stop()
+	CreateStarField(w)
+	return g
+}
+
+// Play future games with options, making a new world with players to suit
+func (g *Game) SetOptions(options Options) {
+	fresh := NewGame(g.inputs, options, g.seed)
+	g.world = fresh.world
+	g.spawnTimer = fresh.spawnTimer
+	g.waveTimer = fresh.waveTimer
+	g.saucerTimer = fresh.saucerTimer
+	g.players = fresh.players
+	g.scoreboard = fresh.scoreboard
+	g.waves = fresh.waves
+}
+
+// Open the window and run the game until it is closed
+func RunGame(g *Game) {
 	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
 	ebiten.SetWindowTitle("Asteroids")
-	CreateStarField()
 	err := ebiten.RunGame(g)
 	if err != nil {
 		panic(err)
 	}
+}
+
+func main() {
+	flag.Parse()
+	if *replayFile != "" {
+		RunReplayFromFlags()
+		return
+	}
+	if *headlessTicks > 0 {
+		RunHeadlessFromFlags()
+		return
+	}
+	bindings := LoadBindings(ControlsFile)
+	pad := &EbitenGamepad{}
+	menuInput := NewMergedInput(NewKeyboardInput(bindings), NewGamepadInput(pad))
+	inputs := [] InputSource {menuInput}
+	if flagOptions.Players() > 1 {
+		inputs = CoopInputs(pad)
+	}
+	var recorder *Recorder
+	if *recordFile != "" {
+		recorder = NewRecorder(*recordFile, inputs...)
+		inputs = recorder.Inputs()
+	}
+	g := NewGame(inputs, flagOptions, *seedFlag)
+	g.SetBindings(bindings)
+	g.gamepad = pad
+	g.menuInput = menuInput
+	g.recorder = recorder
+	g.lobby = NewLobby()
+	if *spectateAddr != "" {
+		g.Spectate(*spectateAddr)
+	}
+	if *hostAddr != "" || *joinAddr != "" {
+		if err := g.JoinNetGame(*hostAddr + *joinAddr, *hostAddr != "", menuInput); err != nil {
+			fmt.Println(err)
+			fmt.Println("Unable to start network game.")
+			return
+		}
+		g.game_mode = Network
+	}
+	RunGame(g)
 }
\ No newline at end of file
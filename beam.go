@@ -0,0 +1,80 @@
+// Laser beams
+// for Asteroids written in Go using Ebitengine
+// A laser beam reaches from the ship's nose across the screen the moment it
+// is fired and hits everything along it, passing through meteors. It only
+// hits on the tick it is fired then fades away over the life of its weapon.
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/paul63/vector2"
+)
+
+const BeamWidth = 3
+
+var beamColor = color.RGBA{255, 80, 80, 255}
+
+// Distance a beam reaches, far enough to cross the screen or when wrapping
+// the missile range up to half the screen height so the beam never reaches
+// round to where it started
+func BeamLength(w *World) float64 {
+	if w.options.wrap {
+		return math.Min(w.options.missileRange, ScreenHeight / 2)
+	}
+	return math.Hypot(ScreenWidth, ScreenHeight)
+}
+
+// Far end of the beam
+func (m *Missile) BeamEnd() vector2.Vector {
+	length := BeamLength(m.world)
+	return vector2.Vector{
+		X: m.position.X + math.Sin(m.angle) * length,
+		Y: m.position.Y - math.Cos(m.angle) * length,
+	}
+}
+
+// Box around the whole of a beam or the square around any other missile,
+// used for the broad phase
+func (m *Missile) Bounds() ScreenPos {
+	if m.weapon.behaviour != MissileBeam {
+		return m.GameSprite.Bounds()
+	}
+	end := m.BeamEnd()
+	width := int(math.Ceil(math.Abs(end.X - m.position.X))) + 1
+	height := int(math.Ceil(math.Abs(end.Y - m.position.Y))) + 1
+	return NewScreenPos((m.position.X + end.X) / 2, (m.position.Y + end.Y) / 2, width, height)
+}
+
+// Check if the missile hits the sprite, a beam hits anything along its
+// length but only on the tick it is fired
+func (w *World) MissileHits(m *Missile, gs *GameSprite) bool {
+	if m.weapon.behaviour == MissileBeam {
+		return m.firing && w.CollidesSegment(m.position, m.BeamEnd(), gs)
+	}
+	return w.Collides(&m.GameSprite, gs)
+}
+
+// Draw the beam fading as its life runs out, when wrapping it is drawn
+// again a screen away in each direction so the part past an edge shows
+func (m *Missile) DrawBeam(screen *ebiten.Image) {
+	end := m.BeamEnd()
+	fade := float64(m.life) / float64(m.weapon.life)
+	c := beamColor
+	c.R, c.G, c.B, c.A = uint8(float64(c.R) * fade), uint8(float64(c.G) * fade), uint8(float64(c.B) * fade), uint8(float64(c.A) * fade)
+	shifts := [] float64 {0}
+	if m.world.options.wrap {
+		shifts = [] float64 {-1, 0, 1}
+	}
+	for _, sx := range shifts {
+		for _, sy := range shifts {
+			dx, dy := sx * ScreenWidth, sy * ScreenHeight
+			vector.StrokeLine(screen, float32(m.position.X + dx), float32(m.position.Y + dy),
+				float32(end.X + dx), float32(end.Y + dy), BeamWidth, c, true)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+// Compares the spatial hash used to find meteors that may collide with
+// checking every pair, and checks the small sets that are left out of the
+// spatial hash cost less checked directly, run with go test -bench .
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/paul63/vector2"
+)
+
+var benchCounts = [] int {100, 1000, 10000}
+
+// A world with count meteors of random sizes and angles scattered over the screen
+func benchWorld(options Options, count int) *World {
+	w := NewWorld(options, 1)
+	for i := 0; i < count; i++ {
+		sprite := meteorSprites[w.rng.IntN(len(meteorSprites))]
+		pos := vector2.Vector{X: w.rng.Float64() * ScreenWidth, Y: w.rng.Float64() * ScreenHeight}
+		angle := w.rng.Float64() * 2 * math.Pi
+		w.meteors = append(w.meteors, &Meteor{GameSprite: NewGameSprite(sprite, pos, vector2.Vector{}, angle), world: w})
+	}
+	return w
+}
+
+// Count the meteors hitting each meteor by checking every other one
+func bruteForceHits(w *World) int {
+	hits := 0
+	for _, a := range w.meteors {
+		for _, b := range w.meteors {
+			if a != b && w.Collides(&a.GameSprite, &b.GameSprite) {
+				hits++
+			}
+		}
+	}
+	return hits
+}
+
+// Count the meteors hitting each meteor by checking those the spatial
+// hash finds near it, rebuilding the hash first as the game does every tick
+func spatialHashHits(w *World) int {
+	w.BuildMeteorGrid()
+	hits := 0
+	for _, a := range w.meteors {
+		for _, b := range w.MeteorsNear(a.Bounds()) {
+			if a != b && w.Collides(&a.GameSprite, &b.GameSprite) {
+				hits++
+			}
+		}
+	}
+	return hits
+}
+
+func TestSpatialHashFindsEveryCollision(t *testing.T) {
+	wrapped := DefaultOptions()
+	wrapped.wrap = true
+	for _, options := range [] Options {DefaultOptions(), wrapped} {
+		for _, count := range [] int {100, 1000} {
+			w := benchWorld(options, count)
+			if a, b := bruteForceHits(w), spatialHashHits(w); a != b {
+				t.Errorf("%d entities wrap=%t: spatial hash found %d collisions but brute force found %d",
+					count, options.wrap, b, a)
+			}
+		}
+	}
+}
+
+func BenchmarkBruteForce(b *testing.B) {
+	for _, count := range benchCounts {
+		w := benchWorld(DefaultOptions(), count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bruteForceHits(w)
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialHash(b *testing.B) {
+	for _, count := range benchCounts {
+		w := benchWorld(DefaultOptions(), count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				spatialHashHits(w)
+			}
+		})
+	}
+}
+
+// Missiles in play for the small set benchmarks, two players firing the
+// spread shot as fast as they can have about 150 on screen
+var benchMissiles = [] int {16, 64, 256}
+
+// The entities checked against each other without a spatial hash:
+// missiles hitting saucers and players flying into everything else
+type smallSets struct {
+	players		[] *GameSprite
+	saucers		[] *GameSprite
+	shots		[] *GameSprite
+	pickups		[] *GameSprite
+	missiles	[] *GameSprite
+	grid		*SpatialHash
+	nearby		[] int
+}
+
+// A busy tick with one saucer, as no more are sent while one is in play,
+// two players, eight saucer shots, four pickups and the missiles given
+func benchSmallSets(w *World, missiles int) *smallSets {
+	scatter := func(sprite *SpriteImage, count int) [] *GameSprite {
+		var sprites [] *GameSprite
+		for i := 0; i < count; i++ {
+			pos := vector2.Vector{X: w.rng.Float64() * ScreenWidth, Y: w.rng.Float64() * ScreenHeight}
+			gs := NewGameSprite(sprite, pos, vector2.Vector{}, w.rng.Float64() * 2 * math.Pi)
+			sprites = append(sprites, &gs)
+		}
+		return sprites
+	}
+	return &smallSets{
+		players:	scatter(playerSprite, 2),
+		saucers:	scatter(saucerSprites[0], 1),
+		shots:		scatter(saucerShotSprite, 8),
+		pickups:	scatter(pickupSprite, 4),
+		missiles:	scatter(missileSprite, missiles),
+		grid:		NewSpatialHash(HashCellSize, w.options.wrap),
+	}
+}
+
+// Count the collisions the game looks for by checking every pair
+func bruteForceSmallHits(w *World, s *smallSets) int {
+	hits := 0
+	for _, miss := range s.missiles {
+		for _, saucer := range s.saucers {
+			if w.Collides(miss, saucer) {
+				hits++
+			}
+		}
+	}
+	for _, p := range s.players {
+		for _, set := range [] [] *GameSprite {s.saucers, s.shots, s.pickups, s.missiles} {
+			for _, other := range set {
+				if w.Collides(p, other) {
+					hits++
+				}
+			}
+		}
+	}
+	return hits
+}
+
+// Count the same collisions by adding everything but the players to a
+// spatial hash each tick, ids run on from one set to the next with the
+// saucers first
+func spatialHashSmallHits(w *World, s *smallSets) int {
+	var all [] *GameSprite
+	for _, set := range [] [] *GameSprite {s.saucers, s.shots, s.pickups, s.missiles} {
+		all = append(all, set...)
+	}
+	s.grid.Clear()
+	for i, gs := range all {
+		s.grid.Insert(i, gs.Bounds())
+	}
+	hits := 0
+	for _, miss := range s.missiles {
+		s.nearby = s.grid.Query(miss.Bounds(), s.nearby[:0])
+		for _, i := range s.nearby {
+			if i < len(s.saucers) && w.Collides(miss, all[i]) {
+				hits++
+			}
+		}
+	}
+	for _, p := range s.players {
+		s.nearby = s.grid.Query(p.Bounds(), s.nearby[:0])
+		for _, i := range s.nearby {
+			if w.Collides(p, all[i]) {
+				hits++
+			}
+		}
+	}
+	return hits
+}
+
+func TestSmallSetsSpatialHashFindsEveryCollision(t *testing.T) {
+	for _, count := range benchMissiles {
+		// Enough ticks of scattered entities for some to collide
+		found, total := 0, 0
+		w := NewWorld(DefaultOptions(), 1)
+		for i := 0; i < 50; i++ {
+			s := benchSmallSets(w, count)
+			a, b := bruteForceSmallHits(w, s), spatialHashSmallHits(w, s)
+			if a != b {
+				t.Fatalf("%d missiles: spatial hash found %d collisions but brute force found %d", count, b, a)
+			}
+			found += b
+			total++
+		}
+		if found == 0 {
+			t.Errorf("%d missiles: no collisions in %d ticks", count, total)
+		}
+	}
+}
+
+func BenchmarkSmallSetsBruteForce(b *testing.B) {
+	for _, count := range benchMissiles {
+		w := NewWorld(DefaultOptions(), 1)
+		s := benchSmallSets(w, count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bruteForceSmallHits(w, s)
+			}
+		})
+	}
+}
+
+func BenchmarkSmallSetsSpatialHash(b *testing.B) {
+	for _, count := range benchMissiles {
+		w := NewWorld(DefaultOptions(), 1)
+		s := benchSmallSets(w, count)
+		b.Run(fmt.Sprint(count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				spatialHashSmallHits(w, s)
+			}
+		})
+	}
+}
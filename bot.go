@@ -0,0 +1,303 @@
+// Autopilot
+// for Asteroids written in Go using Ebitengine
+// A bot that plays in place of a player by looking at the world each tick the
+// way a player watches the screen. It shoots whatever it can hit soonest,
+// leading each shot to where the target will be when the missile gets there,
+// and flies out of the way of anything about to hit the ship, raising the
+// deflector or hyperjumping when it is too late to fly clear. The bot only
+// gives input like any other player so its games record and replay as usual.
+
+package main
+
+import (
+	"flag"
+	"math"
+
+	"github.com/paul63/vector2"
+)
+
+var botFlag = flag.Bool("bot", false, "the autopilot plays every player when running headless")
+
+const (
+	BotHorizon = 90			// Ticks ahead the bot looks for anything that will hit the ship
+	BotDodgeTime = 40		// Anything arriving sooner than this is dodged
+	BotPanicTime = 8		// Anything arriving sooner than this needs the deflector or a hyperjump
+	BotMargin = 10			// Room in pixels the bot keeps between the ship and anything else
+	BotMaxSpeed = 3			// Speed above which the bot slows down with newtonian physics
+	BotThrustAngle = math.Pi / 4	// The bot only thrusts when this close to the way it wants to go
+	AttractIdleTime = 20	// Seconds on the title screen before the autopilot starts playing
+)
+
+// Input for a player worked out from the state of the game
+type Bot struct {
+	game	*Game
+	player	int				// Player the bot flies
+	target	*GameSprite		// What the bot is shooting at
+}
+
+// Something the bot can see with where it is heading
+type botObject struct {
+	sprite		*GameSprite
+	velocity	vector2.Vector		// Distance moved each tick
+	radius		float64
+	shootable	bool		// Can be shot rather than only avoided
+	priority	bool		// Shoots back so is worth shooting first
+}
+
+// Create a bot for each player of the game
+func NewBots(g *Game) [] InputSource {
+	var bots [] InputSource
+	for i := 0; i < g.world.options.Players(); i++ {
+		bots = append(bots, &Bot{game: g, player: i})
+	}
+	return bots
+}
+
+// Difference between two angles from -Pi to Pi
+func AngleDiff(a, b float64) float64 {
+	return math.Remainder(a - b, 2 * math.Pi)
+}
+
+// Angle the ship points in to face along the offset
+func PointingAngle(offset vector2.Vector) float64 {
+	return math.Atan2(offset.X, -offset.Y)
+}
+
+func (b *Bot) Read() InputState {
+	var in InputState
+	if b.player >= len(b.game.players) {
+		return in
+	}
+	p := b.game.players[b.player]
+	if !p.alive {
+		b.target = nil
+		return in
+	}
+	if p.Weapon().behaviour == MissileMine {
+		// Mines are left behind so cannot be aimed
+		in.weapon = WeaponStandard + 1
+	}
+	objects := b.Objects(p)
+	aim, hasTarget := b.Aim(p, objects)
+	heading := aim
+	threat, when := b.NearestThreat(p, objects)
+	switch {
+	case threat != nil && when < BotPanicTime:
+		if p.deflectorEnergy >= DeflectorMinEnergy || p.deflecting {
+			in.Set(ActionDeflector, true)
+		} else if p.hyperJumpTimer <= 0 {
+			in.Set(ActionHyperjump, true)
+		}
+		heading = b.Escape(p, threat)
+		in.Set(ActionThrust, math.Abs(AngleDiff(heading, p.angle)) < BotThrustAngle)
+	case threat != nil && when < BotDodgeTime:
+		heading = b.Escape(p, threat)
+		in.Set(ActionThrust, math.Abs(AngleDiff(heading, p.angle)) < BotThrustAngle)
+	case p.world.options.physics == PhysicsNewtonian && p.movement.Length() > BotMaxSpeed:
+		// Turn against the way the ship is drifting and slow down
+		heading = PointingAngle(vector2.Vector{X: -p.movement.X, Y: -p.movement.Y})
+		in.Set(ActionThrust, math.Abs(AngleDiff(heading, p.angle)) < BotThrustAngle)
+	}
+	// Turn as far as needed this tick, up to the full rotation speed
+	turn := AngleDiff(heading, p.angle) / rotationSpeed * 100
+	in.turn = int(math.Max(-100, math.Min(100, math.Round(turn))))
+	if hasTarget && b.OnTarget(p, aim) {
+		in.Set(ActionFire, true)
+	}
+	return in
+}
+
+// Everything that can hit the ship or be shot, with where it is heading
+func (b *Bot) Objects(p *Player) [] botObject {
+	w := p.world
+	var objects [] botObject
+	add := func(gs *GameSprite, velocity vector2.Vector, shootable, priority bool) {
+		if !gs.done {
+			objects = append(objects, botObject{gs, velocity, gs.sprite.Shape().radius, shootable, priority})
+		}
+	}
+	// Everything but the players and their missiles is slowed by slow time
+	scaled := func(v vector2.Vector) vector2.Vector {
+		v.MultiplyByScalar(w.timeScale)
+		return v
+	}
+	for _, m := range w.meteors {
+		add(&m.GameSprite, scaled(m.movement), true, false)
+	}
+	for _, s := range w.saucers {
+		add(&s.GameSprite, scaled(s.movement), true, true)
+	}
+	for _, s := range w.saucerShots {
+		add(&s.GameSprite, scaled(s.movement), false, false)
+	}
+	if w.options.versus {
+		// The other ship is a target and its missiles are a danger
+		for _, other := range b.game.players {
+			if other != p && other.alive {
+				add(&other.GameSprite, other.Velocity(), true, true)
+			}
+		}
+		for _, m := range w.missiles {
+			if m.owner != p {
+				add(&m.GameSprite, m.movement, false, false)
+			}
+		}
+	}
+	return objects
+}
+
+// Offset from the ship to something, the shortest way round when everything wraps
+func (b *Bot) Offset(p *Player, gs *GameSprite) vector2.Vector {
+	offset := vector2.Vector{X: gs.position.X - p.position.X, Y: gs.position.Y - p.position.Y}
+	if p.world.options.wrap {
+		offset.X = WrapDelta(offset.X, ScreenWidth)
+		offset.Y = WrapDelta(offset.Y, ScreenHeight)
+	}
+	return offset
+}
+
+// First time from 0 at which something at offset moving at velocity is within reach,
+// or -1 if it never is
+func TimeToReach(offset, velocity vector2.Vector, reach float64) float64 {
+	c := offset.X * offset.X + offset.Y * offset.Y - reach * reach
+	if c <= 0 {
+		return 0
+	}
+	a := velocity.X * velocity.X + velocity.Y * velocity.Y
+	bb := offset.X * velocity.X + offset.Y * velocity.Y
+	if a == 0 || bb >= 0 {
+		// Not moving closer
+		return -1
+	}
+	disc := bb * bb - a * c
+	if disc < 0 {
+		return -1
+	}
+	return (-bb - math.Sqrt(disc)) / a
+}
+
+// The object that will hit the ship soonest within the horizon and when
+// Objects it will hit are those whose path brings them within reach of the ship
+// going the way it is now
+func (b *Bot) NearestThreat(p *Player, objects [] botObject) (*botObject, float64) {
+	var threat *botObject
+	soonest := float64(BotHorizon)
+	ship := p.Velocity()
+	shipRadius := p.sprite.Shape().radius
+	for i := range objects {
+		o := &objects[i]
+		relative := vector2.Vector{X: o.velocity.X - ship.X, Y: o.velocity.Y - ship.Y}
+		t := TimeToReach(b.Offset(p, o.sprite), relative, o.radius + shipRadius + BotMargin)
+		if t >= 0 && t < soonest {
+			threat, soonest = o, t
+		}
+	}
+	return threat, soonest
+}
+
+// Way to fly to get clear of the threat, away from where it passes closest
+// to the ship or across its path if it is coming straight at the ship
+func (b *Bot) Escape(p *Player, threat *botObject) float64 {
+	ship := p.Velocity()
+	offset := b.Offset(p, threat.sprite)
+	relative := vector2.Vector{X: threat.velocity.X - ship.X, Y: threat.velocity.Y - ship.Y}
+	closest := offset
+	if speed := relative.X * relative.X + relative.Y * relative.Y; speed > 0 {
+		t := math.Max(0, -(offset.X * relative.X + offset.Y * relative.Y) / speed)
+		closest = vector2.Vector{X: offset.X + relative.X * t, Y: offset.Y + relative.Y * t}
+	}
+	away := vector2.Vector{X: -closest.X, Y: -closest.Y}
+	if away.Length() < 1 {
+		away = vector2.Vector{X: -relative.Y, Y: relative.X}
+	}
+	return PointingAngle(away)
+}
+
+// Time for a missile of speed fired from the ship to meet something at offset
+// moving at velocity, or -1 if it can never catch it
+func InterceptTime(offset, velocity vector2.Vector, speed float64) float64 {
+	a := velocity.X * velocity.X + velocity.Y * velocity.Y - speed * speed
+	bb := 2 * (offset.X * velocity.X + offset.Y * velocity.Y)
+	c := offset.X * offset.X + offset.Y * offset.Y
+	if math.Abs(a) < 1e-9 {
+		if bb >= 0 {
+			return -1
+		}
+		return -c / bb
+	}
+	disc := bb * bb - 4 * a * c
+	if disc < 0 {
+		return -1
+	}
+	root := math.Sqrt(disc)
+	t1, t2 := (-bb - root) / (2 * a), (-bb + root) / (2 * a)
+	if t1 > t2 {
+		t1, t2 = t2, t1
+	}
+	if t1 >= 0 {
+		return t1
+	}
+	return t2
+}
+
+// Choose what to shoot and the angle to fire at to hit it
+// The target is whatever can be hit soonest allowing for the time to turn,
+// saucers and the other ship in versus count as closer as they shoot back,
+// and the bot sticks with its target until another is much easier
+func (b *Bot) Aim(p *Player, objects [] botObject) (float64, bool) {
+	w := p.world
+	speed := p.Weapon().speed
+	beam := p.Weapon().behaviour == MissileBeam
+	bestCost, targetCost := math.Inf(1), math.Inf(1)
+	var best *GameSprite
+	var bestAngle, targetAngle float64
+	for i := range objects {
+		o := &objects[i]
+		if !o.shootable {
+			continue
+		}
+		offset := b.Offset(p, o.sprite)
+		// Missiles move on their own and are not carried along by the ship
+		// and a beam hits at once
+		t := 0.0
+		if !beam {
+			if t = InterceptTime(offset, o.velocity, speed); t < 0 {
+				continue
+			}
+		}
+		hit := vector2.Vector{X: offset.X + o.velocity.X * t, Y: offset.Y + o.velocity.Y * t}
+		if w.options.wrap {
+			if speed * t > w.options.missileRange || (beam && hit.Length() > BeamLength(w)) {
+				continue
+			}
+		} else if x, y := p.position.X + hit.X, p.position.Y + hit.Y; x < 0 || x > ScreenWidth || y < 0 || y > ScreenHeight {
+			continue
+		}
+		angle := PointingAngle(hit)
+		cost := t + math.Abs(AngleDiff(angle, p.angle)) / rotationSpeed
+		if o.priority {
+			cost /= 2
+		}
+		if cost < bestCost {
+			best, bestCost, bestAngle = o.sprite, cost, angle
+		}
+		if o.sprite == b.target {
+			targetCost, targetAngle = cost, angle
+		}
+	}
+	if b.target != nil && targetCost < bestCost * 1.5 {
+		return targetAngle, true
+	}
+	b.target = best
+	return bestAngle, best != nil
+}
+
+// Check the ship points close enough to the angle to hit the target
+// The closer the target the wider the angle that hits it
+func (b *Bot) OnTarget(p *Player, aim float64) bool {
+	offset := b.Offset(p, b.target)
+	distance := math.Max(offset.Length(), 1)
+	radius := b.target.sprite.Shape().radius
+	tolerance := math.Max(math.Atan2(radius * 0.6, distance), rotationSpeed)
+	return math.Abs(AngleDiff(aim, p.angle)) <= tolerance
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Menu input held as set by the test
+type heldInput struct {
+	in	InputState
+}
+
+func (hi *heldInput) Read() InputState {
+	return hi.in
+}
+
+func TestAttractMode(t *testing.T) {
+	menu := &heldInput{}
+	g := NewGame([] InputSource {menu}, DefaultOptions(), 42)
+	g.menuInput = menu
+	for i := 0; i < AttractIdleTime * ebiten.TPS(); i++ {
+		g.Update()
+	}
+	if g.attract == nil || g.game_mode != InPlay {
+		t.Fatalf("autopilot not playing after %d idle seconds, mode %d", AttractIdleTime, g.game_mode)
+	}
+	for i := 0; i < 600; i++ {
+		g.Update()
+	}
+	if TotalScore(g.players) == 0 {
+		t.Error("autopilot has not scored after 600 ticks")
+	}
+	menu.in.Set(ActionFire, true)
+	g.Update()
+	if g.attract != nil || g.game_mode != InPlay || g.ticks != 0 {
+		t.Fatalf("pressing fire left attract %t mode %d ticks %d", g.attract != nil, g.game_mode, g.ticks)
+	}
+	if g.inputs[0] != InputSource(menu) {
+		t.Error("the player's input was not restored")
+	}
+}
+
+func TestAttractModeEndsAtTitle(t *testing.T) {
+	menu := &heldInput{}
+	g := NewGame([] InputSource {menu}, DefaultOptions(), 42)
+	g.menuInput = menu
+	g.StartAttract()
+	for i := 0; i < 200000 && g.attract != nil; i++ {
+		g.Update()
+	}
+	if g.attract != nil || g.game_mode != Inst {
+		t.Errorf("autopilot game over left attract %t mode %d", g.attract != nil, g.game_mode)
+	}
+}
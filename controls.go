@@ -0,0 +1,259 @@
+// Control bindings and the controls screen
+// for Asteroids written in Go using Ebitengine
+// Each action can be bound to any number of keys and mouse buttons.
+// The bindings are loaded from controls.txt and can be changed in game.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const ControlsFile = "controls.txt"
+
+// A key or mouse button that can be bound to an action
+type Control struct {
+	mouse	bool
+	key		ebiten.Key
+	button	ebiten.MouseButton
+}
+
+func KeyControl(key ebiten.Key) Control {
+	return Control{key: key}
+}
+
+func MouseControl(button ebiten.MouseButton) Control {
+	return Control{mouse: true, button: button}
+}
+
+var mouseButtonNames = map[ebiten.MouseButton] string {
+	ebiten.MouseButtonLeft:		"MouseLeft",
+	ebiten.MouseButtonRight:	"MouseRight",
+	ebiten.MouseButtonMiddle:	"MouseMiddle",
+}
+
+func (c Control) String() string {
+	if c.mouse {
+		return mouseButtonNames[c.button]
+	}
+	return c.key.String()
+}
+
+// Find the control with the name given e.g. ArrowUp or MouseLeft
+func ParseControl(name string) (Control, error) {
+	for button, n := range mouseButtonNames {
+		if strings.EqualFold(n, name) {
+			return MouseControl(button), nil
+		}
+	}
+	var key ebiten.Key
+	if err := key.UnmarshalText([] byte(name)); err != nil {
+		return Control{}, fmt.Errorf("unknown control %q", name)
+	}
+	return KeyControl(key), nil
+}
+
+// Check if the control is held down
+func (c Control) Pressed() bool {
+	if c.mouse {
+		return ebiten.IsMouseButtonPressed(c.button)
+	}
+	return ebiten.IsKeyPressed(c.key)
+}
+
+// The controls bound to each action
+type Bindings [NumActions] [] Control
+
+func DefaultBindings() *Bindings {
+	return &Bindings{
+		ActionRotateLeft:	{KeyControl(ebiten.KeyArrowLeft)},
+		ActionRotateRight:	{KeyControl(ebiten.KeyArrowRight)},
+		ActionThrust:		{KeyControl(ebiten.KeyArrowUp), MouseControl(ebiten.MouseButtonMiddle)},
+		ActionReverse:		{KeyControl(ebiten.KeyArrowDown)},
+		ActionFire:			{KeyControl(ebiten.KeySpace)},
+		ActionAimFire:		{MouseControl(ebiten.MouseButtonLeft)},
+		ActionHyperjump:	{KeyControl(ebiten.KeyH), MouseControl(ebiten.MouseButtonRight)},
+		ActionDeflector:	{KeyControl(ebiten.KeyS)},
+		ActionNextWeapon:	{KeyControl(ebiten.KeyE)},
+		ActionPrevWeapon:	{KeyControl(ebiten.KeyQ)},
+		ActionPause:		{KeyControl(ebiten.KeyEscape), KeyControl(ebiten.KeyP)},
+		ActionStart:		{KeyControl(ebiten.KeySpace)},
+	}
+}
+
+// Controls for each player in co-op and versus, the first player keeps the arrows and
+// mouse and the second uses WASD with F to fire and G to hyperjump
+func CoopBindings() [MaxPlayers] *Bindings {
+	return [MaxPlayers] *Bindings {
+		{
+			ActionRotateLeft:	{KeyControl(ebiten.KeyArrowLeft)},
+			ActionRotateRight:	{KeyControl(ebiten.KeyArrowRight)},
+			ActionThrust:		{KeyControl(ebiten.KeyArrowUp), MouseControl(ebiten.MouseButtonMiddle)},
+			ActionReverse:		{KeyControl(ebiten.KeyArrowDown)},
+			ActionFire:			{KeyControl(ebiten.KeySpace)},
+			ActionAimFire:		{MouseControl(ebiten.MouseButtonLeft)},
+			ActionHyperjump:	{KeyControl(ebiten.KeyH), MouseControl(ebiten.MouseButtonRight)},
+			ActionDeflector:	{KeyControl(ebiten.KeyShiftRight)},
+			ActionNextWeapon:	{KeyControl(ebiten.KeyPeriod)},
+			ActionPrevWeapon:	{KeyControl(ebiten.KeyComma)},
+		},
+		{
+			ActionRotateLeft:	{KeyControl(ebiten.KeyA)},
+			ActionRotateRight:	{KeyControl(ebiten.KeyD)},
+			ActionThrust:		{KeyControl(ebiten.KeyW)},
+			ActionReverse:		{KeyControl(ebiten.KeyS)},
+			ActionFire:			{KeyControl(ebiten.KeyF)},
+			ActionHyperjump:	{KeyControl(ebiten.KeyG)},
+			ActionDeflector:	{KeyControl(ebiten.KeyR)},
+			ActionNextWeapon:	{KeyControl(ebiten.KeyE)},
+			ActionPrevWeapon:	{KeyControl(ebiten.KeyQ)},
+		},
+	}
+}
+
+// Names of the controls bound to the action for display
+func (b *Bindings) Names(a Action) string {
+	if len(b[a]) == 0 {
+		return "(none)"
+	}
+	var names [] string
+	for _, c := range b[a] {
+		names = append(names, c.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// Read bindings with a line for each action such as "thrust = ArrowUp, MouseMiddle"
+// Actions not in the file keep their default controls
+func ReadBindings(r io.Reader) (*Bindings, error) {
+	b := DefaultBindings()
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, list, found := strings.Cut(text, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected action = controls", line)
+		}
+		a, ok := ParseAction(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown action %q", line, strings.TrimSpace(name))
+		}
+		b[a] = nil
+		for _, field := range strings.Split(list, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			c, err := ParseControl(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			b[a] = append(b[a], c)
+		}
+	}
+	return b, scanner.Err()
+}
+
+// Load bindings from the file name - if it cannot be read the defaults are used
+func LoadBindings(name string) *Bindings {
+	f, err := os.Open(name)
+	if err != nil {
+		return DefaultBindings()
+	}
+	defer f.Close()
+	b, err := ReadBindings(f)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("Unable to read controls, using defaults.")
+		return DefaultBindings()
+	}
+	return b
+}
+
+func (b *Bindings) Write(w io.Writer) error {
+	for a, controls := range b {
+		var names [] string
+		for _, c := range controls {
+			names = append(names, c.String())
+		}
+		_, err := fmt.Fprintf(w, "%s = %s\n", Action(a), strings.Join(names, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bindings) Save(name string) {
+	f, err := os.Create(name)
+	if err == nil {
+		err = b.Write(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("Unable to write controls file.")
+	}
+}
+
+// Screen used to change the controls bound to each action
+// Up and down select an action, Enter waits for a new control
+// Backspace restores the default and Escape returns to the title
+type ControlsScreen struct {
+	bindings	*Bindings
+	selected	Action
+	waiting		bool
+}
+
+func NewControlsScreen(bindings *Bindings) *ControlsScreen {
+	return &ControlsScreen{bindings: bindings}
+}
+
+// Process the keys for the screen and return true when finished
+func (cs *ControlsScreen) Update() bool {
+	if cs.waiting {
+		// Bind the first key or mouse button pressed
+		keys := inpututil.AppendJustPressedKeys(nil)
+		if len(keys) > 0 {
+			cs.bindings[cs.selected] = [] Control {KeyControl(keys[0])}
+			cs.waiting = false
+			return false
+		}
+		for button := range mouseButtonNames {
+			if inpututil.IsMouseButtonJustPressed(button) {
+				cs.bindings[cs.selected] = [] Control {MouseControl(button)}
+				cs.waiting = false
+				return false
+			}
+		}
+		return false
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		cs.selected = (cs.selected + NumActions - 1) % NumActions
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		cs.selected = (cs.selected + 1) % NumActions
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		cs.waiting = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		cs.bindings[cs.selected] = DefaultBindings()[cs.selected]
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		cs.bindings.Save(ControlsFile)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Deflector shield
+// for Asteroids written in Go using Ebitengine
+// While the deflector key is held a bubble around the ship bounces meteors
+// away and absorbs saucer shots. It drains an energy meter that slowly
+// recharges while the deflector is down.
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/paul63/vector2"
+)
+
+const (
+	MaxDeflectorEnergy = 100
+	DeflectorDrain = 0.6		// Energy used each tick the deflector is up
+	DeflectorRecharge = 0.15	// Energy regained each tick the deflector is down
+	DeflectorMinEnergy = 20		// Energy needed to raise the deflector
+	DeflectorBounceCost = 5		// Energy used bouncing a meteor for each size
+	DeflectorGap = 8			// Gap between the ship and the bubble
+	DeflectorBarWidth = 150
+	DeflectorBarHeight = 10
+)
+
+var (
+	deflectorColor color.Color = color.RGBA{0, 255, 255, 255}
+	deflectorFill color.Color = color.RGBA{0, 80, 80, 60}
+	deflectorLowColor color.Color = color.RGBA{120, 120, 120, 255}
+)
+
+// Raise the deflector while its action is held and there is energy left,
+// otherwise recharge it (called every tick from Player.Update)
+func (p *Player) UpdateDeflector(in InputState) {
+	canRaise := p.deflecting || p.deflectorEnergy >= DeflectorMinEnergy
+	if in.Held(ActionDeflector) && canRaise && p.deflectorEnergy > 0 {
+		p.deflecting = true
+		p.deflectorEnergy = math.Max(p.deflectorEnergy - DeflectorDrain, 0)
+	} else {
+		p.deflecting = false
+		p.deflectorEnergy = math.Min(p.deflectorEnergy + DeflectorRecharge, MaxDeflectorEnergy)
+	}
+}
+
+// Radius of the bubble around the ship
+func (p *Player) DeflectorRadius() float64 {
+	return playerSprite.Shape().radius + DeflectorGap
+}
+
+// Bounce meteors off the player's deflector and absorb saucer shots
+// Returns true if the deflector is up so the ship cannot be hit
+func (g *Game) Deflect(p *Player) bool {
+	if !p.deflecting {
+		return false
+	}
+	radius := p.DeflectorRadius()
+	size := int(math.Ceil(radius * 2))
+	for _, met := range g.world.MeteorsNear(NewScreenPos(p.position.X, p.position.Y, size, size)) {
+		if g.world.CollidesCircle(p.position, radius, &met.GameSprite) {
+			p.Bounce(met)
+		}
+	}
+	for _, shot := range g.world.saucerShots {
+		if g.world.CollidesCircle(p.position, radius, &shot.GameSprite) {
+			shot.done = true
+		}
+	}
+	return true
+}
+
+// Reflect the meteor's movement off the bubble and move it clear so it
+// only bounces once
+func (p *Player) Bounce(met *Meteor) {
+	normal := vector2.Vector{X: met.position.X - p.position.X, Y: met.position.Y - p.position.Y}
+	if p.world.options.wrap {
+		normal.X = WrapDelta(normal.X, ScreenWidth)
+		normal.Y = WrapDelta(normal.Y, ScreenHeight)
+	}
+	if normal.Length() == 0 {
+		normal = vector2.Vector{X: 0, Y: -1}
+	}
+	normal.Normalize()
+	// Movement relative to the ship so a moving ship pushes the meteor
+	velocity := p.Velocity()
+	relative := vector2.Vector{X: met.movement.X - velocity.X, Y: met.movement.Y - velocity.Y}
+	if along := relative.Dot(normal); along < 0 {
+		met.movement.X -= 2 * along * normal.X
+		met.movement.Y -= 2 * along * normal.Y
+	}
+	clear := p.DeflectorRadius() + met.sprite.Shape().radius
+	met.position.X = p.position.X + normal.X * clear
+	met.position.Y = p.position.Y + normal.Y * clear
+	p.deflectorEnergy = math.Max(p.deflectorEnergy - DeflectorBounceCost * float64(met.size + 1), 0)
+}
+
+// Draw the bubble around the ship while the deflector is up
+func (p *Player) DrawDeflector(screen *ebiten.Image) {
+	if p.alive && p.deflecting {
+		x, y, r := float32(p.position.X), float32(p.position.Y), float32(p.DeflectorRadius())
+		vector.DrawFilledCircle(screen, x, y, r, deflectorFill, true)
+		vector.StrokeCircle(screen, x, y, r, 2, deflectorColor, true)
+	}
+}
+
+// Draw the deflector energy as a bar with its top left corner at x, y
+func (sb *ScoreBoard) DrawDeflectorBar(screen *ebiten.Image, player *Player, x, y float32) {
+	c := deflectorColor
+	if !player.deflecting && player.deflectorEnergy < DeflectorMinEnergy {
+		c = deflectorLowColor
+	}
+	fill := float32(player.deflectorEnergy / MaxDeflectorEnergy * DeflectorBarWidth)
+	vector.DrawFilledRect(screen, x, y, fill, DeflectorBarHeight, c, false)
+	vector.StrokeRect(screen, x, y, DeflectorBarWidth, DeflectorBarHeight, 1, white, false)
+}
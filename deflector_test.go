@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paul63/vector2"
+)
+
+func TestBounceOffStationaryShipKeepsSpeed(t *testing.T) {
+	g := NewGame(nil, DefaultOptions(), 1)
+	p := g.players[0]
+	// Thrust once and coast to a stop so movement is left pointing the way
+	// the ship faces
+	var in InputState
+	in.Set(ActionThrust, true)
+	p.Update(in)
+	for i := 0; i < 1000 && p.thrust > 0; i++ {
+		p.Update(InputState{})
+	}
+	if v := p.Velocity(); p.thrust != 0 || v.X != 0 || v.Y != 0 || p.movement.Length() == 0 {
+		t.Fatalf("ship still moving at %v with thrust %v", v, p.thrust)
+	}
+	// Rocks heading straight at the ship from every side
+	for i := 0; i < 8; i++ {
+		angle := float64(i) * math.Pi / 4
+		out := vector2.Vector{X: math.Cos(angle), Y: math.Sin(angle)}
+		pos := vector2.Vector{X: p.position.X + out.X * 40, Y: p.position.Y + out.Y * 40}
+		movement := vector2.Vector{X: -out.X * 2, Y: -out.Y * 2}
+		met := &Meteor{GameSprite: NewGameSprite(meteorSprites[0], pos, movement, 0), world: g.world}
+		p.Bounce(met)
+		if speed := met.movement.Length(); math.Abs(speed - 2) > 1e-9 {
+			t.Errorf("rock from angle %v bounced at speed %v, want 2", angle, speed)
+		}
+		if met.movement.Dot(out) <= 0 {
+			t.Errorf("rock from angle %v still heading for the ship at %v", angle, met.movement)
+		}
+	}
+}
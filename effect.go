@@ -0,0 +1,107 @@
+// Player effects
+// for Asteroids written in Go using Ebitengine
+// Effects are given to the player by collecting power-ups and last for
+// a number of ticks. Collecting the same power-up again restarts its time.
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+type Effect int
+
+const (
+	EffectShield Effect = iota		// Meteors, saucers and shots are destroyed on contact
+	EffectRapidFire					// Missiles reload faster
+	EffectSpread					// Three missiles are fired at once
+	EffectSlowTime					// Everything but the player moves at half speed
+	NumEffects
+)
+
+const (
+	SpreadAngle = 0.2		// Angle in radians between spread missiles
+	SlowTimeScale = 0.5		// Speed of the world while time is slowed
+)
+
+var (
+	effectNames = [NumEffects] string {"Shield", "Rapid fire", "Spread", "Slow time"}
+	effectTicks = [NumEffects] int {600, 720, 720, 480}
+	effectColors = [NumEffects] color.Color {pickupColors[PickupShield], pickupColors[PickupRapidFire],
+		pickupColors[PickupSpread], pickupColors[PickupSlowTime]}
+)
+
+func (e Effect) Name() string {
+	return effectNames[e]
+}
+
+// Give the player the effect for its full time
+func (p *Player) AddEffect(e Effect) {
+	p.effects[e] = effectTicks[e]
+	if e == EffectSlowTime {
+		p.world.SetTimeScale(SlowTimeScale)
+	}
+}
+
+func (p *Player) HasEffect(e Effect) bool {
+	return p.effects[e] > 0
+}
+
+// Seconds left of the effect at normal speed
+func (p *Player) EffectRemaining(e Effect) float64 {
+	return float64(p.effects[e]) / float64(ebiten.TPS())
+}
+
+// Count down the effects the player has (called every tick while alive)
+func (p *Player) UpdateEffects() {
+	for e := range p.effects {
+		if p.effects[e] > 0 {
+			p.effects[e] -= 1
+			if p.effects[e] == 0 && Effect(e) == EffectSlowTime {
+				// Time stays slow if another player still has the effect
+				p.world.UpdateTimeScale()
+			}
+		}
+	}
+}
+
+// Remove every effect when the player is hit or a game starts
+func (p *Player) ClearEffects() {
+	p.effects = [NumEffects] int {}
+	p.world.UpdateTimeScale()
+}
+
+// Slow time while any player still has the effect
+func (w *World) UpdateTimeScale() {
+	scale := 1.0
+	for _, p := range w.players {
+		if p.HasEffect(EffectSlowTime) {
+			scale = SlowTimeScale
+		}
+	}
+	w.SetTimeScale(scale)
+}
+
+// Time between missiles from the current weapon, shorter with rapid fire
+func (p *Player) ReloadTime() int {
+	reload := p.Weapon().reload
+	if p.HasEffect(EffectRapidFire) {
+		return int(math.Ceil(float64(reload) * RapidReloadScale))
+	}
+	return reload
+}
+
+// Draw the shield bubble around the ship if it has one
+func (p *Player) DrawEffects(screen *ebiten.Image) {
+	if p.alive && p.HasEffect(EffectShield) {
+		// Flash when the shield is about to run out
+		if p.effects[EffectShield] > 120 || p.effects[EffectShield] / 8 % 2 == 0 {
+			vector.StrokeCircle(screen, float32(p.position.X), float32(p.position.Y),
+				float32(p.sprite.Shape().radius + 6), 2, effectColors[EffectShield], true)
+		}
+	}
+}
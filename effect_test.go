@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSlowTimeLastsWhileAnyPlayerHasIt(t *testing.T) {
+	options := DefaultOptions()
+	options.coop = true
+	g := NewGame(nil, options, 1)
+	first, second := g.players[0], g.players[1]
+	first.AddEffect(EffectSlowTime)
+	second.AddEffect(EffectSlowTime)
+	first.effects[EffectSlowTime] = 1
+	first.UpdateEffects()
+	if g.world.timeScale != SlowTimeScale {
+		t.Fatalf("time scale %v after one player's slow time ran out", g.world.timeScale)
+	}
+	second.effects[EffectSlowTime] = 1
+	second.UpdateEffects()
+	if g.world.timeScale != 1 {
+		t.Errorf("time scale %v after every player's slow time ran out", g.world.timeScale)
+	}
+}
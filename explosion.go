@@ -7,37 +7,34 @@ package main
 
 import (
 	"image/color"
-	"math/rand/v2"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 	"github.com/paul63/vector2"
 )
 
-var explosions [] *Explosion
-
-func UpdateAllExplosions(){
-	for _, e := range explosions {
+func (w *World) UpdateAllExplosions(){
+	for _, e := range w.explosions {
 		e.Update()
 	} 
 }
 
-func DrawAllExplosions(screen *ebiten.Image){
-	for _, e := range explosions {
+func (w *World) DrawAllExplosions(screen *ebiten.Image){
+	for _, e := range w.explosions {
 		e.Draw(screen)
 	} 
 }
 
-func ClearDoneExplosions(){
-	for i, e := range(explosions){
+func (w *World) ClearDoneExplosions(){
+	for i, e := range(w.explosions){
 		if e.done{
-			explosions = append(explosions[:i], explosions[i+1:]...)
+			w.explosions = append(w.explosions[:i], w.explosions[i+1:]...)
 			break
 		}		
 	}
 }
 
-func ClearAllExplosions(){
-	explosions = nil
+func (w *World) ClearAllExplosions(){
+	w.explosions = nil
 }
 
 type Explosion struct{
@@ -50,18 +47,18 @@ type Explosion struct{
 	particles [] *Particle
 }
 
-// Create a new explosion and add to list
+// Create a new explosion and add to the world's list
 // x, y = Center of explosion. int the number of particles. 
 // Color = color of particles. rate = the rate that the prticles fade away
-func NewExplosion( x_pos, y_pos float64, size int, color color.Color, rate float64) *Explosion{
+func NewExplosion(w *World, x_pos, y_pos float64, size int, color color.Color, rate float64) *Explosion{
 	var particles [] *Particle
 	// create particles of random size and direction
 	for i := 0; i < size * 2; i++{
 		part := NewParticle(x_pos, y_pos, 
-							rand.Float64() * 4,
+							w.rng.Float64() * 4,
 							color,
-							(rand.Float64() - 0.5) * rand.Float64() * 6,
-							(rand.Float64() - 0.5) * rand.Float64() * 6,
+							(w.rng.Float64() - 0.5) * w.rng.Float64() * 6,
+							(w.rng.Float64() - 0.5) * w.rng.Float64() * 6,
 							rate)
 		particles = append(particles, part)
 	}
@@ -74,10 +71,30 @@ func NewExplosion( x_pos, y_pos float64, size int, color color.Color, rate float
 			done: false,
 			particles: particles,
 	}
-	explosions = append(explosions, &exp)
+	w.explosions = append(w.explosions, &exp)
+	return &exp
+}
+
+// Create an explosion that particles are added to one at a time with Emit,
+// such as a trail of exhaust, starting with a single particle at x, y
+func NewTrail(w *World, x, y float64, color color.Color, rate float64) *Explosion {
+	exp := Explosion{
+		x: x,
+		y: y,
+		size: 1,
+		color: color,
+		rate: rate,
+		particles: [] *Particle {NewParticle(x, y, 2, color, 0, 0, rate)},
+	}
+	w.explosions = append(w.explosions, &exp)
 	return &exp
 }
 
+// Add a particle of radius at x, y moving by velocity
+func (e *Explosion) Emit(x, y, radius float64, velocity vector2.Vector) {
+	e.particles = append(e.particles, NewParticle(x, y, radius, e.color, velocity.X, velocity.Y, e.rate))
+}
+
 func (e *Explosion) Update(){
 	for i, p := range(e.particles){
 		p.Update()
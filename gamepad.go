@@ -0,0 +1,168 @@
+// Gamepad input
+// for Asteroids written in Go using Ebitengine
+// Uses the standard gamepad layout:
+//    Left stick or d-pad left and right to rotate, stick up to thrust
+//    Right trigger or right shoulder to fire
+//    B (right face button) to hyperjump, A (bottom face button) to reverse
+//    Start to pause or start a new game
+
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Stick movement smaller than this is ignored
+const StickDeadZone = 0.2
+
+// A gamepad in the standard layout
+// Implemented by EbitenGamepad for real devices and FakeGamepad for testing
+type Gamepad interface {
+	Connected() bool
+	Pressed(button ebiten.StandardGamepadButton) bool
+	Axis(axis ebiten.StandardGamepadAxis) float64
+}
+
+// The first connected gamepad with a standard layout
+// Gamepads can be plugged in or removed while the game is running
+type EbitenGamepad struct {
+	id			ebiten.GamepadID
+	connected	bool
+}
+
+// Check for the gamepad being disconnected or a new one being connected
+func (eg *EbitenGamepad) Connected() bool {
+	if eg.connected && inpututil.IsGamepadJustDisconnected(eg.id) {
+		eg.connected = false
+	}
+	if !eg.connected {
+		for _, id := range ebiten.AppendGamepadIDs(nil) {
+			if ebiten.IsStandardGamepadLayoutAvailable(id) {
+				eg.id = id
+				eg.connected = true
+				break
+			}
+		}
+	}
+	return eg.connected
+}
+
+func (eg *EbitenGamepad) Pressed(button ebiten.StandardGamepadButton) bool {
+	return eg.connected && ebiten.IsStandardGamepadButtonPressed(eg.id, button)
+}
+
+func (eg *EbitenGamepad) Axis(axis ebiten.StandardGamepadAxis) float64 {
+	if !eg.connected {
+		return 0
+	}
+	return ebiten.StandardGamepadAxisValue(eg.id, axis)
+}
+
+// Gamepad with buttons and axes set directly, for tests and scripts
+type FakeGamepad struct {
+	connected	bool
+	buttons		map[ebiten.StandardGamepadButton] bool
+	axes		map[ebiten.StandardGamepadAxis] float64
+}
+
+func NewFakeGamepad() *FakeGamepad {
+	return &FakeGamepad{
+		connected:	true,
+		buttons:	map[ebiten.StandardGamepadButton] bool {},
+		axes:		map[ebiten.StandardGamepadAxis] float64 {},
+	}
+}
+
+// Simulate the gamepad being plugged in or removed
+func (fg *FakeGamepad) SetConnected(connected bool) {
+	fg.connected = connected
+}
+
+func (fg *FakeGamepad) SetButton(button ebiten.StandardGamepadButton, pressed bool) {
+	fg.buttons[button] = pressed
+}
+
+func (fg *FakeGamepad) SetAxis(axis ebiten.StandardGamepadAxis, value float64) {
+	fg.axes[axis] = value
+}
+
+func (fg *FakeGamepad) Connected() bool {
+	return fg.connected
+}
+
+func (fg *FakeGamepad) Pressed(button ebiten.StandardGamepadButton) bool {
+	return fg.connected && fg.buttons[button]
+}
+
+func (fg *FakeGamepad) Axis(axis ebiten.StandardGamepadAxis) float64 {
+	if !fg.connected {
+		return 0
+	}
+	return fg.axes[axis]
+}
+
+// The gamepad buttons used for each action
+var gamepadButtons = map[Action] [] ebiten.StandardGamepadButton {
+	ActionRotateLeft:	{ebiten.StandardGamepadButtonLeftLeft},
+	ActionRotateRight:	{ebiten.StandardGamepadButtonLeftRight},
+	ActionReverse:		{ebiten.StandardGamepadButtonRightBottom},
+	ActionFire:			{ebiten.StandardGamepadButtonFrontBottomRight, ebiten.StandardGamepadButtonFrontTopRight},
+	ActionHyperjump:	{ebiten.StandardGamepadButtonRightRight},
+	ActionDeflector:	{ebiten.StandardGamepadButtonRightLeft, ebiten.StandardGamepadButtonFrontBottomLeft},
+	ActionNextWeapon:	{ebiten.StandardGamepadButtonLeftTop},
+	ActionPrevWeapon:	{ebiten.StandardGamepadButtonLeftBottom},
+	ActionPause:		{ebiten.StandardGamepadButtonCenterRight},
+	ActionStart:		{ebiten.StandardGamepadButtonCenterRight},
+}
+
+// Names of the gamepad buttons shown in the instructions
+var gamepadButtonNames = map[Action] string {
+	ActionRotateLeft:	"Left stick or d-pad left",
+	ActionRotateRight:	"Left stick or d-pad right",
+	ActionThrust:		"Left stick up",
+	ActionReverse:		"A",
+	ActionFire:			"Right trigger or shoulder",
+	ActionHyperjump:	"B",
+	ActionDeflector:	"X or left trigger",
+	ActionNextWeapon:	"D-pad up",
+	ActionPrevWeapon:	"D-pad down",
+	ActionPause:		"Start",
+	ActionStart:		"Start",
+}
+
+// Input read from a gamepad
+type GamepadInput struct {
+	pad		Gamepad
+}
+
+func NewGamepadInput(pad Gamepad) *GamepadInput {
+	return &GamepadInput{pad: pad}
+}
+
+func (gi *GamepadInput) Read() InputState {
+	var in InputState
+	if !gi.pad.Connected() {
+		return in
+	}
+	for a, buttons := range gamepadButtons {
+		for _, b := range buttons {
+			if gi.pad.Pressed(b) {
+				in.Set(a, true)
+				break
+			}
+		}
+	}
+	x := gi.pad.Axis(ebiten.StandardGamepadAxisLeftStickHorizontal)
+	y := gi.pad.Axis(ebiten.StandardGamepadAxisLeftStickVertical)
+	if math.Abs(x) > StickDeadZone {
+		in.turn = int(math.Round(x * 100))
+	}
+	// Stick is negative when pushed up
+	if y < -StickDeadZone {
+		in.push = int(math.Round(-y * 100))
+	}
+	return in
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestGamepadStick(t *testing.T) {
+	pad := NewFakeGamepad()
+	gi := NewGamepadInput(pad)
+	pad.SetAxis(ebiten.StandardGamepadAxisLeftStickHorizontal, -0.5)
+	pad.SetAxis(ebiten.StandardGamepadAxisLeftStickVertical, -0.8)
+	if in := gi.Read(); in.turn != -50 || in.push != 80 {
+		t.Errorf("stick left and up read as turn %d push %d", in.turn, in.push)
+	}
+	// Inside the dead zone and pulling back do nothing
+	pad.SetAxis(ebiten.StandardGamepadAxisLeftStickHorizontal, StickDeadZone / 2)
+	pad.SetAxis(ebiten.StandardGamepadAxisLeftStickVertical, 0.9)
+	if in := gi.Read(); in.turn != 0 || in.push != 0 {
+		t.Errorf("stick in the dead zone and down read as turn %d push %d", in.turn, in.push)
+	}
+}
+
+func TestGamepadTriggerFires(t *testing.T) {
+	pad := NewFakeGamepad()
+	gi := NewGamepadInput(pad)
+	if gi.Read().Held(ActionFire) {
+		t.Error("fire held with nothing pressed")
+	}
+	pad.SetButton(ebiten.StandardGamepadButtonFrontBottomRight, true)
+	if in := gi.Read(); !in.Held(ActionFire) || in.Held(ActionHyperjump) {
+		t.Errorf("right trigger read as %+v", in)
+	}
+}
+
+func TestGamepadDisconnect(t *testing.T) {
+	pad := NewFakeGamepad()
+	gi := NewGamepadInput(pad)
+	pad.SetButton(ebiten.StandardGamepadButtonFrontBottomRight, true)
+	pad.SetAxis(ebiten.StandardGamepadAxisLeftStickHorizontal, 1)
+	pad.SetConnected(false)
+	if in := gi.Read(); in != (InputState{}) {
+		t.Errorf("disconnected gamepad read as %+v", in)
+	}
+	// Plugged back in with the trigger still held
+	pad.SetConnected(true)
+	if in := gi.Read(); !in.Held(ActionFire) || in.turn != 100 {
+		t.Errorf("reconnected gamepad read as %+v", in)
+	}
+}
@@ -0,0 +1,217 @@
+// Headless driver
+// for Asteroids written in Go using Ebitengine
+// Runs the game loop without a window or GPU so it can be used in CI.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	headlessTicks = flag.Int("headless", 0, "run headless for this many ticks and report the score")
+	headlessScript = flag.String("script", "", "input script used when running headless")
+	headlessScript2 = flag.String("script2", "", "input script for the second player when running headless in co-op")
+	headlessRuns = flag.Int("runs", 1, "number of games to play headless, each with the next seed")
+)
+
+// Input played back from a list of per tick states
+// Once the script runs out no keys are pressed
+type ScriptedInput struct {
+	states	[] InputState
+	tick	int
+}
+
+func (si *ScriptedInput) Read() InputState {
+	var in InputState
+	if si.tick < len(si.states) {
+		in = si.states[si.tick]
+	}
+	si.tick++
+	return in
+}
+
+// Read a script where each line is a number of ticks followed by the
+// actions held for those ticks e.g. "60 left fire"
+// Actions are named as in the controls file, x,y sets the cursor position
+// and turn=N or push=N give analog stick movement as read from a gamepad.
+// weapon=N chooses a weapon as the number keys do.
+// Lines starting with # are ignored.
+func ParseScript(r io.Reader) (*ScriptedInput, error) {
+	si := &ScriptedInput{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		ticks, err := strconv.Atoi(fields[0])
+		if err != nil || ticks < 0 {
+			return nil, fmt.Errorf("line %d: invalid tick count %q", line, fields[0])
+		}
+		var in InputState
+		for _, name := range fields[1:] {
+			if a, ok := ParseAction(name); ok {
+				in.Set(a, true)
+				continue
+			}
+			var err error
+			if value, found := strings.CutPrefix(name, "turn="); found {
+				in.turn, err = strconv.Atoi(value)
+			} else if value, found := strings.CutPrefix(name, "push="); found {
+				in.push, err = strconv.Atoi(value)
+			} else if value, found := strings.CutPrefix(name, "weapon="); found {
+				in.weapon, err = strconv.Atoi(value)
+			} else {
+				_, err = fmt.Sscanf(name, "%d,%d", &in.cursorX, &in.cursorY)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: unknown action %q", line, name)
+			}
+		}
+		for i := 0; i < ticks; i++ {
+			si.states = append(si.states, in)
+		}
+	}
+	return si, scanner.Err()
+}
+
+// Read the script in the file name, with no name nothing is ever pressed
+func LoadScript(name string) (*ScriptedInput, error) {
+	if name == "" {
+		return &ScriptedInput{}, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseScript(f)
+}
+
+// Result of a headless run
+type HeadlessResult struct {
+	seed		uint64
+	ticks		int
+	score		int
+	lives		int
+	wave		int
+	gameOver	bool
+	scores		[] int		// Score of each player
+	kills		[] int		// Kills of each player in versus
+	round		int
+}
+
+// The score and lives are totals for every player, with two players the
+// score of each is added at the end followed by the kills in versus
+func (r HeadlessResult) String() string {
+	s := fmt.Sprintf("seed: %d ticks: %d score: %d lives: %d wave: %d game over: %t",
+		r.seed, r.ticks, r.score, r.lives, r.wave, r.gameOver)
+	if len(r.scores) > 1 {
+		s += fmt.Sprint(" player scores: ", r.scores)
+	}
+	if len(r.kills) > 0 {
+		s += fmt.Sprint(" kills: ", r.kills, " round: ", r.round)
+	}
+	return s
+}
+
+// Start a new game and step it for up to ticks updates
+// Stops early if the game ends
+func RunHeadless(g *Game, ticks int) HeadlessResult {
+	g.Start()
+	played := 0
+	for played < ticks && g.Playing() {
+		if err := g.Update(); err != nil {
+			panic(err)
+		}
+		played++
+	}
+	return g.Result()
+}
+
+// Result of the game so far
+func (g *Game) Result() HeadlessResult {
+	result := HeadlessResult{
+		seed:		g.world.seed,
+		ticks:		g.ticks,
+		score:		TotalScore(g.players),
+		wave:		g.wave,
+		gameOver:	g.game_mode == GameOver,
+	}
+	for _, p := range g.players {
+		result.lives += p.lives
+		result.scores = append(result.scores, p.score)
+		if g.world.options.versus {
+			result.kills = append(result.kills, p.kills)
+			result.round = g.round
+		}
+	}
+	return result
+}
+
+// Input for each player of the game, from the scripts or the autopilot with -bot
+func HeadlessInputs(g *Game) ([] InputSource, error) {
+	if *botFlag {
+		return NewBots(g), nil
+	}
+	var inputs [] InputSource
+	for _, name := range [] string {*headlessScript, *headlessScript2}[:g.world.options.Players()] {
+		script, err := LoadScript(name)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, script)
+	}
+	return inputs, nil
+}
+
+// Run headless using the command line flags and print the result
+// With -runs each game after the first uses the next seed and the
+// averages are printed at the end
+func RunHeadlessFromFlags() {
+	if *hostAddr != "" || *joinAddr != "" {
+		RunNetHeadless()
+		return
+	}
+	runs := max(*headlessRuns, 1)
+	var ticks, score, wave int
+	for run := 0; run < runs; run++ {
+		seed := *seedFlag
+		if seed != 0 {
+			seed += uint64(run)
+		}
+		g := NewGame(nil, flagOptions, seed)
+		inputs, err := HeadlessInputs(g)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if *recordFile != "" {
+			g.recorder = NewRecorder(*recordFile, inputs...)
+			inputs = g.recorder.Inputs()
+		}
+		g.inputs = inputs
+		result := RunHeadless(g, *headlessTicks)
+		// Save the recording even if the game is still in play
+		if g.recorder != nil {
+			g.recorder.Finish(result.score, false)
+		}
+		fmt.Println(result)
+		ticks += result.ticks
+		score += result.score
+		wave += result.wave
+	}
+	if runs > 1 {
+		fmt.Printf("runs: %d average ticks: %.1f average score: %.1f average wave: %.2f\n",
+			runs, float64(ticks) / float64(runs), float64(score) / float64(runs), float64(wave) / float64(runs))
+	}
+}
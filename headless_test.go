@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testScript = `# spin, thrust now and then and fire
+120 left fire
+30 thrust fire
+200 right fire weapon=1
+60 turn=-50 push=80 fire
+20000 left fire
+`
+
+// Play a game headless with the script as the only player's input
+func playScript(t *testing.T, seed uint64, ticks int) HeadlessResult {
+	t.Helper()
+	script, err := ParseScript(strings.NewReader(testScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return RunHeadless(NewGame([] InputSource {script}, DefaultOptions(), seed), ticks)
+}
+
+func TestParseScript(t *testing.T) {
+	si, err := ParseScript(strings.NewReader(testScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(si.states) != 120 + 30 + 200 + 60 + 20000 {
+		t.Fatalf("script has %d ticks", len(si.states))
+	}
+	in := si.states[400]
+	if !in.Held(ActionFire) || in.turn != -50 || in.push != 80 {
+		t.Errorf("tick 400 is %+v", in)
+	}
+	if in := si.states[200]; in.weapon != 1 || !in.Held(ActionRotateRight) {
+		t.Errorf("tick 200 is %+v", in)
+	}
+}
+
+func TestParseScriptRejectsBadLines(t *testing.T) {
+	for _, line := range [] string {
+		"fire",
+		"-5 fire",
+		"10 jump",
+		"10 turn=left",
+		"10 weapon=",
+	} {
+		if _, err := ParseScript(strings.NewReader(line)); err == nil {
+			t.Errorf("%q was accepted", line)
+		}
+	}
+}
+
+func TestHeadlessIsDeterministic(t *testing.T) {
+	first := playScript(t, 42, 20000)
+	second := playScript(t, 42, 20000)
+	if !first.gameOver {
+		t.Errorf("game still in play after %d ticks", first.ticks)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed and script played %v then %v", first, second)
+	}
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	script, err := ParseScript(strings.NewReader(testScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(nil, DefaultOptions(), 7)
+	g.recorder = NewRecorder(t.TempDir() + "/unused.rep", script)
+	g.inputs = g.recorder.Inputs()
+	// Stop while the game is still in play so the recording is not saved
+	result := RunHeadless(g, 1500)
+	recorded := g.recorder.replay
+	recorded.score = result.score
+
+	var buf bytes.Buffer
+	if err := recorded.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.seed != 7 || r.options != DefaultOptions() || r.Ticks() != result.ticks {
+		t.Fatalf("read back seed %d options %v ticks %d", r.seed, r.options, r.Ticks())
+	}
+	if !reflect.DeepEqual(r.waves, g.waves) {
+		t.Fatalf("read back waves %v", r.waves)
+	}
+	played := RunHeadless(r.NewGame(), r.Ticks())
+	if err := r.Verify(played.ticks, played.score, played.gameOver); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReplayOfQuitGame(t *testing.T) {
+	script, err := ParseScript(strings.NewReader(testScript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := t.TempDir() + "/quit.rep"
+	menu := &heldInput{}
+	g := NewGame(nil, DefaultOptions(), 11)
+	g.recorder = NewRecorder(name, script)
+	g.inputs = g.recorder.Inputs()
+	g.Start()
+	for i := 0; i < 500; i++ {
+		g.Update()
+	}
+	// Pause then move up from Resume round to Quit to title and choose it
+	// (the menu input is only given once paused as without a window the
+	// game would pause itself for not having the focus)
+	g.Pause()
+	g.menuInput = menu
+	for _, a := range [] Action {ActionThrust, ActionFire} {
+		menu.in.Set(a, true)
+		g.Update()
+		menu.in = InputState{}
+		g.Update()
+	}
+	if g.game_mode != Inst {
+		t.Fatalf("mode %d after quitting from the pause menu", g.game_mode)
+	}
+	quitAt, score := g.ticks, TotalScore(g.players)
+
+	r, err := LoadReplay(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ended || r.Ticks() != quitAt {
+		t.Fatalf("recorded ended %t after %d ticks, quit after %d", r.ended, r.Ticks(), quitAt)
+	}
+	played := RunHeadless(r.NewGame(), r.Ticks())
+	if err := r.Verify(played.ticks, played.score, played.gameOver); err != nil {
+		t.Error("headless:", err)
+	}
+	// Played back as in the window it stops where the game was quit
+	g = r.NewGame()
+	g.replay = r
+	g.Start()
+	for i := 0; i < 100000 && g.Playing(); i++ {
+		g.Update()
+	}
+	if g.game_mode != Inst || g.ticks != quitAt || TotalScore(g.players) != score {
+		t.Errorf("replay stopped in mode %d after %d ticks scoring %d", g.game_mode, g.ticks, TotalScore(g.players))
+	}
+}
@@ -0,0 +1,82 @@
+// Homing missiles
+// for Asteroids written in Go using Ebitengine
+// A homing missile locks on to the nearest meteor and turns towards it a
+// little each tick. If its target is destroyed it locks on to the next
+// nearest. It leaves a trail of exhaust particles and runs out of fuel
+// after the life of its weapon.
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/paul63/vector2"
+)
+
+const (
+	HomingTurnRate = 0.06	// Most the missile can turn in a tick in radians
+	TrailRate = 0.12		// Rate the exhaust particles shrink
+)
+
+var trailColor color.Color = color.RGBA{255, 160, 60, 160}
+
+// Turn towards the target, choosing a new one if it has been destroyed,
+// and keep flying at the weapon's speed in the direction pointed
+func (m *Missile) Steer() {
+	if m.target == nil || m.target.done {
+		m.target = m.NearestMeteor()
+	}
+	if m.target != nil {
+		offset := vector2.Vector{X: m.target.position.X - m.position.X, Y: m.target.position.Y - m.position.Y}
+		if m.world.options.wrap {
+			offset.X = WrapDelta(offset.X, ScreenWidth)
+			offset.Y = WrapDelta(offset.Y, ScreenHeight)
+		}
+		// Angle to the target measured as the ship's angle is, 0 pointing up
+		wanted := math.Atan2(offset.X, -offset.Y)
+		turn := math.Remainder(wanted - m.angle, 2 * math.Pi)
+		m.angle += math.Max(-HomingTurnRate, math.Min(turn, HomingTurnRate))
+	}
+	m.movement = vector2.Vector{
+		X: math.Sin(m.angle) * m.weapon.speed,
+		Y: math.Cos(m.angle) * -m.weapon.speed,
+	}
+}
+
+// Closest meteor still in play or nil if there are none
+func (m *Missile) NearestMeteor() *Meteor {
+	var nearest *Meteor
+	best := math.Inf(1)
+	for _, met := range m.world.meteors {
+		if met.done {
+			continue
+		}
+		dx, dy := met.position.X - m.position.X, met.position.Y - m.position.Y
+		if m.world.options.wrap {
+			dx, dy = WrapDelta(dx, ScreenWidth), WrapDelta(dy, ScreenHeight)
+		}
+		if d := dx * dx + dy * dy; d < best {
+			nearest, best = met, d
+		}
+	}
+	return nearest
+}
+
+// Leave an exhaust particle behind the missile drifting away from it
+// The background random source is used so the trail never changes play
+func (m *Missile) EmitTrail() {
+	back := vector2.Vector{X: -math.Sin(m.angle), Y: math.Cos(m.angle)}
+	x := m.position.X + back.X * float64(m.height / 2)
+	y := m.position.Y + back.Y * float64(m.height / 2)
+	if m.trail == nil {
+		m.trail = NewTrail(m.world, x, y, trailColor, TrailRate)
+		return
+	}
+	rng := m.world.starRng
+	drift := vector2.Vector{
+		X: back.X * 0.5 + (rng.Float64() - 0.5) * 0.4,
+		Y: back.Y * 0.5 + (rng.Float64() - 0.5) * 0.4,
+	}
+	m.trail.Emit(x, y, 1 + rng.Float64() * 1.5, drift)
+}
@@ -0,0 +1,191 @@
+// Input state and sources
+// for Asteroids written in Go using Ebitengine
+// The game reads the actions held once per tick into an InputState so the
+// simulation can be driven by the keyboard and mouse or by a script.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Something the player can do, the controls for each are set by Bindings
+type Action int
+
+const (
+	ActionRotateLeft Action = iota
+	ActionRotateRight
+	ActionThrust
+	ActionReverse
+	ActionFire
+	ActionAimFire		// Point the ship at the cursor and fire
+	ActionHyperjump
+	ActionDeflector		// Hold to raise the deflector shield
+	ActionNextWeapon
+	ActionPrevWeapon
+	ActionPause
+	ActionStart
+	NumActions
+)
+
+// Names used for actions in the controls file and input scripts
+var actionNames = [NumActions] string {"left", "right", "thrust", "reverse",
+	"fire", "aim", "hyperjump", "deflector", "nextweapon", "prevweapon", "pause", "start"}
+
+// Names shown on screen
+var actionTitles = [NumActions] string {"Rotate left", "Rotate right", "Thrust", "Reverse",
+	"Fire", "Aim at pointer and fire", "Hyperjump", "Deflector shield",
+	"Next weapon (or mouse wheel)", "Previous weapon", "Pause", "Start game"}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+func (a Action) Title() string {
+	return actionTitles[a]
+}
+
+// Find the action with the name given
+func ParseAction(name string) (Action, bool) {
+	for a, n := range actionNames {
+		if strings.EqualFold(n, name) {
+			return Action(a), true
+		}
+	}
+	return 0, false
+}
+
+// The actions held, the cursor position and any analog stick
+// movement in a single tick
+type InputState struct {
+	actions		[NumActions] bool
+	cursorX		int
+	cursorY		int
+	turn		int		// Analog rotation -100 (left) to 100 (right)
+	push		int		// Analog thrust 0 to 100
+	weapon		int		// Weapon chosen by number this tick counting from 1, 0 for none
+}
+
+// Check if the action is held this tick
+func (in InputState) Held(a Action) bool {
+	return in.actions[a]
+}
+
+func (in *InputState) Set(a Action, held bool) {
+	in.actions[a] = held
+}
+
+// Pack the actions held into a single value
+func (in InputState) Buttons() uint64 {
+	var b uint64
+	for a, held := range in.actions {
+		if held {
+			b |= 1 << a
+		}
+	}
+	return b
+}
+
+// Set the actions held from a value packed by Buttons
+func (in *InputState) SetButtons(b uint64) {
+	for a := range in.actions {
+		in.actions[a] = b & (1 << a) != 0
+	}
+}
+
+// Anything that can supply the input for the next tick
+type InputSource interface {
+	Read() InputState
+}
+
+// Input read from the keyboard and mouse through ebiten
+// using the controls bound to each action
+type KeyboardInput struct {
+	bindings	*Bindings
+	weaponKeys	bool		// Read the mouse wheel and number keys
+}
+
+func NewKeyboardInput(bindings *Bindings) *KeyboardInput {
+	return &KeyboardInput{bindings: bindings, weaponKeys: true}
+}
+
+// Input for each player in co-op and versus using the co-op bindings
+// Only the first player has the gamepad, mouse wheel and number keys
+func CoopInputs(pad Gamepad) [] InputSource {
+	bindings := CoopBindings()
+	second := NewKeyboardInput(bindings[1])
+	second.weaponKeys = false
+	return [] InputSource {NewMergedInput(NewKeyboardInput(bindings[0]), NewGamepadInput(pad)), second}
+}
+
+func (ki *KeyboardInput) Read() InputState {
+	var in InputState
+	for a, controls := range ki.bindings {
+		for _, c := range controls {
+			if c.Pressed() {
+				in.actions[a] = true
+				break
+			}
+		}
+	}
+	in.cursorX, in.cursorY = ebiten.CursorPosition()
+	if !ki.weaponKeys {
+		return in
+	}
+	// The wheel and number keys are not rebindable
+	_, wheel := ebiten.Wheel()
+	if wheel > 0 {
+		in.actions[ActionNextWeapon] = true
+	} else if wheel < 0 {
+		in.actions[ActionPrevWeapon] = true
+	}
+	for n := 0; n < NumWeapons; n++ {
+		if ebiten.IsKeyPressed(ebiten.Key1 + ebiten.Key(n)) {
+			in.weapon = n + 1
+		}
+	}
+	return in
+}
+
+// Input combined from several sources such as the keyboard and a gamepad
+// An action is held if it is held on any source. The cursor comes from the
+// first source and the analog movement from the largest push.
+type MergedInput struct {
+	sources		[] InputSource
+}
+
+func NewMergedInput(sources ...InputSource) *MergedInput {
+	return &MergedInput{sources: sources}
+}
+
+func (mi *MergedInput) Read() InputState {
+	var in InputState
+	for i, source := range mi.sources {
+		s := source.Read()
+		for a, held := range s.actions {
+			in.actions[a] = in.actions[a] || held
+		}
+		if i == 0 {
+			in.cursorX, in.cursorY = s.cursorX, s.cursorY
+		}
+		if abs(s.turn) > abs(in.turn) {
+			in.turn = s.turn
+		}
+		if s.push > in.push {
+			in.push = s.push
+		}
+		if in.weapon == 0 {
+			in.weapon = s.weapon
+		}
+	}
+	return in
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
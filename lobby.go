@@ -0,0 +1,199 @@
+// Network game screen
+// for Asteroids written in Go using Ebitengine
+// Hosts a network game or joins one hosted on another machine and switches
+// the game between playing locally and playing over the network.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	LobbyHost = iota
+	LobbyJoin
+	NumLobbyItems
+)
+
+var (
+	lobbyItems = [NumLobbyItems] string {"Host a game on", "Join the game at"}
+	red color.Color = color.RGBA{255, 60, 60, 255}
+)
+
+// Up and down choose to host or join, typing changes the address
+// and Enter hosts or joins
+type Lobby struct {
+	selected	int
+	addresses	[NumLobbyItems] string
+	message		string		// Why the last attempt failed
+}
+
+func NewLobby() *Lobby {
+	return &Lobby{addresses: [NumLobbyItems] string {DefaultNetAddress, "127.0.0.1" + DefaultNetAddress}}
+}
+
+// What the game was set up to play before a network game or attract mode,
+// restored when it is left
+type OfflineSetup struct {
+	inputs		[] InputSource
+	options		Options		// Only changed by a network game
+	recorder	*Recorder
+}
+
+// Process the keys for the network game screen
+// Once hosting or joining it waits for the other player then starts the game
+func (g *Game) UpdateLobby() {
+	lb := g.lobby
+	if g.net != nil {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.LeaveNetGame()
+			g.game_mode = Network
+			return
+		}
+		g.UpdateNetStart(false)
+		return
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		lb.selected = (lb.selected + NumLobbyItems - 1) % NumLobbyItems
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		lb.selected = (lb.selected + 1) % NumLobbyItems
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		address := []rune(lb.addresses[lb.selected])
+		if len(address) > 0 {
+			lb.addresses[lb.selected] = string(address[:len(address) - 1])
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		address := strings.TrimSpace(lb.addresses[lb.selected])
+		lb.message = ""
+		if err := g.JoinNetGame(address, lb.selected == LobbyHost, g.menuInput); err != nil {
+			lb.message = err.Error()
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.game_mode = Inst
+	default:
+		lb.addresses[lb.selected] += string(ebiten.AppendInputChars(nil))
+	}
+}
+
+// Host a network game on address, or join the one hosted there, with the
+// local player's input read from local
+// The game is played in co-op unless the options are for two players already
+func (g *Game) JoinNetGame(address string, host bool, local InputSource) error {
+	var ns *NetSession
+	var err error
+	if text := WavesText(g.waves); host && len(text) > maxNetWavesLength {
+		return fmt.Errorf("the waves are too long to send to the other player, %d characters when at most %d fit",
+			len(text), maxNetWavesLength)
+	}
+	if host {
+		ns, err = NewHostSession(address, local)
+	} else {
+		ns, err = NewJoinSession(address, local)
+	}
+	if err != nil {
+		return err
+	}
+	g.UseNetSession(ns)
+	return nil
+}
+
+// Play future games over the network session
+func (g *Game) UseNetSession(ns *NetSession) {
+	g.offline = &OfflineSetup{inputs: g.inputs, options: g.world.options, recorder: g.recorder}
+	g.net = ns
+	g.inputs = ns.Inputs()
+	if g.recorder != nil {
+		g.recorder = NewRecorder(g.recorder.fileName, g.inputs...)
+		g.inputs = g.recorder.Inputs()
+	}
+	options := g.world.options
+	if options.Players() < 2 {
+		options.coop = true
+	}
+	g.SetOptions(options)
+}
+
+// Begin a network game when both players are ready
+// The host starts as soon as the other player joins and again when start is
+// pressed after a game, once the other player has finished it. The other
+// player starts when the host's start arrives.
+func (g *Game) UpdateNetStart(start bool) {
+	ns := g.net
+	ns.Update()
+	if ns.host {
+		if ns.Connected() && (ns.game == 0 || (start && ns.Settled())) {
+			g.Start()
+		}
+	} else if ns.TakeStart() {
+		g.SetOptions(ns.options)
+		g.waves = ns.waves
+		g.Start()
+	}
+}
+
+// Leave the network game and go back to playing locally
+func (g *Game) LeaveNetGame() {
+	if g.Playing() {
+		g.stop(false)
+	}
+	g.net.Close()
+	g.net = nil
+	g.inputs = g.offline.inputs
+	g.recorder = g.offline.recorder
+	g.SetOptions(g.offline.options)
+	g.offline = nil
+	g.game_mode = Inst
+}
+
+// Show the choice of hosting or joining, or who is being waited for
+func (sb *ScoreBoard) DrawLobby(screen *ebiten.Image, lb *Lobby, ns *NetSession){
+	sb.DrawCenter(screen, "Asteroids - Network Co-op", ScreenWidth/2, 20, 40, yellow)
+	if ns != nil {
+		status := "Looking for the game at " + ns.remote.String()
+		if ns.host {
+			status = "Waiting for a player to join on " + ns.conn.LocalAddr().String()
+		}
+		sb.DrawCenter(screen, status, ScreenWidth/2, 360, 30, white)
+		sb.DrawCenter(screen, "Escape to cancel", ScreenWidth/2, 640, 20, white)
+		return
+	}
+	for i, item := range lobbyItems {
+		c := white
+		if i == lb.selected {
+			c = aqua
+		}
+		sb.DrawLeft(screen, item, 200, 300 + i * 60, 30, c)
+		sb.DrawLeft(screen, lb.addresses[i], 520, 300 + i * 60, 30, c)
+	}
+	if lb.message != "" {
+		sb.DrawCenter(screen, lb.message, ScreenWidth/2, 480, 20, red)
+	}
+	sb.DrawCenter(screen, "The host is player 1 and the player who joins is player 2, each using their own controls",
+		ScreenWidth/2, 560, 20, white)
+	sb.DrawCenter(screen, "Up and down arrows to select, type to change the address, Enter to start",
+		ScreenWidth/2, 640, 20, white)
+	sb.DrawCenter(screen, "Escape to return", ScreenWidth/2, 680, 20, white)
+}
+
+// Show the ping time during a network game with any problem with the connection
+func (sb *ScoreBoard) DrawNetStatus(screen *ebiten.Image, ns *NetSession, playing bool){
+	sb.DrawCenter(screen, fmt.Sprintf("Ping: %d ms", ns.ping.Milliseconds()), ScreenWidth/2, ScreenHeight - 30, 18, white)
+	switch {
+	case ns.Lost():
+		sb.DrawCenter(screen, "Lost the connection to the other player, Escape to leave", ScreenWidth/2, 300, 24, red)
+	case playing && ns.Stalled():
+		sb.DrawCenter(screen, "Waiting for the other player", ScreenWidth/2, 300, 24, aqua)
+	case !playing && !ns.host:
+		sb.DrawCenter(screen, "Waiting for the host to start the next game", ScreenWidth/2, 760, 20, aqua)
+	}
+	if ns.desync >= 0 {
+		sb.DrawCenter(screen, fmt.Sprintf("Out of step with the other player since tick %d", ns.desync),
+			ScreenWidth/2, 340, 24, red)
+	}
+}
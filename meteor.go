@@ -9,59 +9,63 @@ package main
 
 import (
 	"image/color"
-	"math/rand/v2"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/paul63/vector2"
 )
 
 var (
-	meteors [] *Meteor
-	meteorSprites = [] *ebiten.Image {LoadImage("assets/asteroidTiny.png"),
- 									LoadImage("assets/asteroidSmall.png"),
- 									LoadImage("assets/asteroidMed.png"),
-									LoadImage("assets/asteroidLarge.png")}
+	meteorSprites = [] *SpriteImage {LoadSprite("assets/asteroidTiny.png"),
+ 									LoadSprite("assets/asteroidSmall.png"),
+ 									LoadSprite("assets/asteroidMed.png"),
+									LoadSprite("assets/asteroidLarge.png")}
 	scores = [] int {100, 75, 50, 25}
 	expColor color.Color = color.RGBA{255, 125, 125, 100}
 )
 
-func UpdateAllMeteors(){
-	for _, m := range meteors {
+func (w *World) UpdateAllMeteors(){
+	for _, m := range w.meteors {
 		m.Update()
 	} 
 }
 
-func DrawAllMeteors(screen *ebiten.Image){
-	for _, m := range meteors {
+func (w *World) DrawAllMeteors(screen *ebiten.Image){
+	for _, m := range w.meteors {
 		m.Draw(screen)
 	} 
 }
 
-func ClearDoneMeteors(){
-	for i, m := range(meteors){
+func (w *World) ClearDoneMeteors(){
+	for i, m := range(w.meteors){
 		if m.done{
 			// Clear first done found - will eventually clear all done as called every frame
-			meteors = append(meteors[:i], meteors[i+1:]...)
+			w.meteors = append(w.meteors[:i], w.meteors[i+1:]...)
 			break
 		}		
 	}
 }
 
-func ClearAllMeteors(){
-	meteors = nil
+func (w *World) ClearAllMeteors(){
+	w.meteors = nil
 }
 
 type Meteor struct {
 	GameSprite
+	world			*World
 	rotationSpeed	float64
 	size			int
 }
 
-// To create a new Meteor and add to list
-// Player position used as destination of meteor
-func NewMeteor(player *Player) *Meteor {
-	size := rand.IntN(4)
+// To create a new Meteor of random size and add to the world's list
+// Position of a live player used as destination of meteor
+func NewMeteor(w *World) *Meteor {
+	return NewSizedMeteor(w, w.rng.IntN(4))
+}
+
+// To create a new Meteor of size (0 to 3) entering from a screen edge
+func NewSizedMeteor(w *World, size int) *Meteor {
 	sprite := meteorSprites[size]
+	player := w.TargetPlayer()
 
 	// set destination to player position
 	target := vector2.Vector{
@@ -69,7 +73,7 @@ func NewMeteor(player *Player) *Meteor {
 		Y: player.position.Y,
 	}
 	// select a screen edge to enter from
-	edge := rand.IntN(4)
+	edge := w.rng.IntN(4)
 	var (
 		x int
 		y int
@@ -77,16 +81,16 @@ func NewMeteor(player *Player) *Meteor {
 	switch edge{
 	case 0:
 		x = -10
-		y = rand.IntN(ScreenHeight)
+		y = w.rng.IntN(ScreenHeight)
 	case 1:
 		x = ScreenWidth + 10
-		y = rand.IntN(ScreenHeight)  // Corrected was ScreenWidth
+		y = w.rng.IntN(ScreenHeight)  // Corrected was ScreenWidth
 	case 2:
 		y = - 10
-		x = rand.IntN(ScreenWidth)
+		x = w.rng.IntN(ScreenWidth)
 	default:
 		y = ScreenHeight + 10
-		x = rand.IntN(ScreenWidth)  // Corrected was ScreenHeight
+		x = w.rng.IntN(ScreenWidth)  // Corrected was ScreenHeight
 	}
 	pos := vector2.Vector{
 		X: float64(x),
@@ -94,7 +98,7 @@ func NewMeteor(player *Player) *Meteor {
 	}
 
 	// Randomized velocity
-	velocity := 0.25 + rand.Float64()*1.5
+	velocity := (0.25 + w.rng.Float64()*1.5) * w.meteorSpeed
 
 	// Direction is the target minus the current position
 	direction := vector2.Vector{
@@ -110,23 +114,27 @@ func NewMeteor(player *Player) *Meteor {
 		Y: direction.Y * velocity,
 	}
 
-	rotationSpeed := -0.02 + rand.Float64()*0.04
+	rotationSpeed := -0.02 + w.rng.Float64()*0.04
 
 	gameSprite := NewGameSprite(sprite, pos, movement, 0)
 
 	meteor := Meteor{
 		GameSprite: gameSprite,
+		world: w,
 		rotationSpeed: rotationSpeed,
 		size:     size,
 	}
-	// Add to list of meteors
-	meteors = append(meteors, &meteor)
+	if w.options.procedural {
+		meteor.SetSprite(NewRockSprite(NewRockOutline(w.rng, RockRadius(size))))
+	}
+	// Add to world's list of meteors
+	w.meteors = append(w.meteors, &meteor)
 
 	return &meteor
 }
 
 // To create a new Meteor of size (0 to 3) at position x, y
-func NewFragment(size int, x, y float64) *Meteor {
+func NewFragment(w *World, size int, x, y float64) *Meteor {
 	if size > 3 {
 		size = 3
 	}
@@ -134,8 +142,8 @@ func NewFragment(size int, x, y float64) *Meteor {
 
 	// select a random target	
 	target := vector2.Vector{
-		X: float64(rand.IntN(ScreenWidth)),
-		Y: float64(rand.IntN(ScreenHeight)),
+		X: float64(w.rng.IntN(ScreenWidth)),
+		Y: float64(w.rng.IntN(ScreenHeight)),
 	}
 	pos := vector2.Vector{
 		X: float64(x),
@@ -143,7 +151,7 @@ func NewFragment(size int, x, y float64) *Meteor {
 	}
 
 	// Randomized velocity
-	velocity := 0.25 + rand.Float64()*1.5
+	velocity := (0.25 + w.rng.Float64()*1.5) * w.meteorSpeed
 
 	// Direction is the target minus the current position
 	direction := vector2.Vector{
@@ -159,17 +167,18 @@ func NewFragment(size int, x, y float64) *Meteor {
 		Y: direction.Y * velocity,
 	}
 
-	rotationSpeed := -0.02 + rand.Float64()*0.04
+	rotationSpeed := -0.02 + w.rng.Float64()*0.04
 
 	gameSprite := NewGameSprite(sprite, pos, movement, 0)
 
 	meteor := Meteor{
 		GameSprite: gameSprite,
+		world: w,
 		rotationSpeed: rotationSpeed,
 		size:     size,
 	}
-	// Add to list of meteors
-	meteors = append(meteors, &meteor)
+	// Add to world's list of meteors
+	w.meteors = append(w.meteors, &meteor)
 
 	return &meteor
 }
@@ -186,37 +195,66 @@ func (m *Meteor) ScreenPos() ScreenPos {
 
 func (m *Meteor) Update() {
 	if !m.done {
-		m.position.Add(m.movement)
-		m.angle += m.rotationSpeed
-		m.done =  m.position.X < -50 || m.position.X > ScreenWidth + 50 ||
-				m.position.Y < -50 || m.position.Y > ScreenHeight + 50
+		step := m.movement
+		step.MultiplyByScalar(m.world.timeScale)
+		m.position.Add(step)
+		m.angle += m.rotationSpeed * m.world.timeScale
+		if m.world.options.wrap {
+			WrapPosition(&m.position)
+		} else {
+			m.done =  m.position.X < -50 || m.position.X > ScreenWidth + 50 ||
+					m.position.Y < -50 || m.position.Y > ScreenHeight + 50
+		}
 	}
 }
 
 func (m *Meteor) Draw(screen *ebiten.Image) {
-	m.DrawImage(screen)
+	if m.world.options.wrap {
+		m.DrawWrapped(screen)
+	} else {
+		m.DrawImage(screen)
+	}
 }
 
 // Process meteor being hit and return score based on size
 func (m *Meteor) Hit(explode bool) int{
+	return m.Damage(1, explode)
+}
+
+// Knock levels sizes off the meteor in one hit and return the score for
+// each size lost, it splits in 2 once however many sizes it loses
+func (m *Meteor) Damage(levels int, explode bool) int {
 	if !m.done {
-		score := scores[m.size]
-		m.size -= 1
+		score := 0
+		for i := 0; i < levels && m.size - i >= 0; i++ {
+			score += scores[m.size - i]
+		}
+		m.size -= levels
 		if m.size < 0 {
 			m.done = true
 			if explode {
-				NewExplosion(m.position.X, m.position.Y, 20, 
+				NewExplosion(m.world, m.position.X, m.position.Y, 20, 
 					expColor, 0.075)
+				DropPickup(m.world, m.position.X, m.position.Y)
 			}
 		} else {
-			m.sprite = meteorSprites[m.size]
-			m.width = m.sprite.Bounds().Dx()
-			m.height = m.sprite.Bounds().Dy()
-			// split in 2 by creating a new fragment
-			NewFragment(m.size, m.position.X, m.position.Y)
+			if m.sprite.outline != nil && levels == 1 {
+				// cut the rock in 2 so the fragment is the other piece
+				m.Split()
+			} else if m.sprite.outline != nil {
+				// the halves would be too big for the size left so both are new rocks
+				fragment := NewFragment(m.world, m.size, m.position.X, m.position.Y)
+				for _, met := range [] *Meteor {m, fragment} {
+					met.SetSprite(NewRockSprite(NewRockOutline(m.world.rng, RockRadius(met.size))))
+				}
+			} else {
+				m.SetSprite(meteorSprites[m.size])
+				// split in 2 by creating a new fragment
+				NewFragment(m.world, m.size, m.position.X, m.position.Y)
+			}
 			// Create Explosion
 			if explode {
-				NewExplosion(m.position.X, m.position.Y, 20 * (m.size + 1), 
+				NewExplosion(m.world, m.position.X, m.position.Y, 20 * (m.size + 1), 
 				expColor, 0.060 - (0.020 * float64(m.size)))
 			}
 			}
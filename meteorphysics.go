@@ -0,0 +1,74 @@
+// Meteor to meteor collisions
+// for Asteroids written in Go using Ebitengine
+// With the meteor collisions option on meteors bounce elastically off each
+// other. Each meteor has a mass proportional to its size and a large meteor
+// hitting a much smaller one fast enough breaks the smaller one.
+
+package main
+
+import (
+	"slices"
+
+	"github.com/paul63/vector2"
+)
+
+const (
+	FractureMassRatio = 2	// Least times heavier a meteor must be to break another
+	FractureSpeed = 1.2		// Least closing speed in pixels per tick to break a meteor
+)
+
+// Mass of a meteor of size (0 to 3), tiny meteors still have some mass
+func MeteorMass(size int) float64 {
+	return float64(size + 1)
+}
+
+// Bounce every pair of touching meteors off each other
+// (call each tick after BuildMeteorGrid)
+func (w *World) CollideMeteors() {
+	// Fragments made while breaking meteors are not in the grid and
+	// are left until the next tick
+	count := len(w.meteors)
+	for i := 0; i < count; i++ {
+		a := w.meteors[i]
+		w.nearby = w.meteorGrid.Query(a.Bounds(), w.nearby[:0])
+		slices.Sort(w.nearby)
+		for _, j := range w.nearby {
+			// Each pair only once
+			if j > i && w.Collides(&a.GameSprite, &w.meteors[j].GameSprite) {
+				Bounce(w, a, w.meteors[j])
+			}
+		}
+	}
+}
+
+// Exchange momentum between two touching meteors along the line between
+// their centres, nothing happens if they are already moving apart
+func Bounce(w *World, a, b *Meteor) {
+	normal := vector2.Vector{X: b.position.X - a.position.X, Y: b.position.Y - a.position.Y}
+	if w.options.wrap {
+		normal.X = WrapDelta(normal.X, ScreenWidth)
+		normal.Y = WrapDelta(normal.Y, ScreenHeight)
+	}
+	if normal.Length() == 0 {
+		return
+	}
+	normal.Normalize()
+	closing := (a.movement.X - b.movement.X) * normal.X + (a.movement.Y - b.movement.Y) * normal.Y
+	if closing <= 0 {
+		return
+	}
+	massA, massB := MeteorMass(a.size), MeteorMass(b.size)
+	// Impulse of a perfectly elastic collision
+	impulse := 2 * closing / (1 / massA + 1 / massB)
+	a.movement.X -= impulse / massA * normal.X
+	a.movement.Y -= impulse / massA * normal.Y
+	b.movement.X += impulse / massB * normal.X
+	b.movement.Y += impulse / massB * normal.Y
+	if closing >= FractureSpeed {
+		if massA >= massB * FractureMassRatio {
+			b.Hit(true)
+		} else if massB >= massA * FractureMassRatio {
+			a.Hit(true)
+		}
+	}
+}
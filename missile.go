@@ -12,42 +12,50 @@ import (
 )
 
 var (
-	missileSprite = LoadImage("assets/bullet.png")
-	missiles [] *Missile
+	missileSprite = LoadSprite("assets/bullet.png")
 )
-func UpdateAllMissiles(){
-	for _, m := range missiles {
+func (w *World) UpdateAllMissiles(){
+	for _, m := range w.missiles {
 		m.Update()
 	} 
 }
 
-func DrawAllMissiles(screen *ebiten.Image){
-	for _, m := range missiles {
+func (w *World) DrawAllMissiles(screen *ebiten.Image){
+	for _, m := range w.missiles {
 		m.Draw(screen)
 	} 
 }
 
-func ClearDoneMissiles(){
-	for i, m := range(missiles){
+func (w *World) ClearDoneMissiles(){
+	for i, m := range(w.missiles){
 		if m.done{
-			missiles = append(missiles[:i], missiles[i+1:]...)
+			w.missiles = append(w.missiles[:i], w.missiles[i+1:]...)
 			break
 		}		
 	}
 }
 
-func ClearAllMissiles(){
-	missiles = nil
+func (w *World) ClearAllMissiles(){
+	w.missiles = nil
 }
 
 type Missile struct {
 	GameSprite
+	world		*World
+	owner		*Player		// Player who fired the missile and scores for its hits
+	weapon		*Weapon		// Weapon that fired the missile
+	travelled	float64		// Distance moved, used to limit range when wrapping
+	life		int			// Ticks left for missiles with a limited life
+	struck		[] *Meteor	// Meteors already hit by a piercing missile
+	target		*Meteor		// Meteor a homing missile is steering towards
+	trail		*Explosion	// Particles left behind a homing missile
+	firing		bool		// A beam is being fired this tick so can hit
 }
 
-// Create missile at x, y, rotated by angle and traveling toward target
-func NewMissile(pos vector2.Vector, angle float64) *Missile {
-	speed := 5.0
-	sprite := missileSprite
+// Create missile fired by owner's weapon at x, y, rotated by angle and traveling toward target
+func NewMissile(w *World, owner *Player, weapon *Weapon, pos vector2.Vector, angle float64) *Missile {
+	speed := weapon.speed
+	sprite := weapon.sprite
 
 	// Calculate a target so flies in direction ship pointing
 	target := vector2.Vector{
@@ -65,19 +73,23 @@ func NewMissile(pos vector2.Vector, angle float64) *Missile {
 					Y: direction.Y * speed,
 	}
 
-	// move forward so appears at front of ship
-	pos.X += movement.X * 4
-	pos.Y += movement.Y * 4
+	// move forward so appears at front of ship, or behind it when dropped
+	pos.X += direction.X * weapon.offset
+	pos.Y += direction.Y * weapon.offset
 
 	gameSprite := NewGameSprite(sprite, pos, movement, angle)
 
 	//create missile
 	missile := Missile{
 		GameSprite: gameSprite,
+		world: w,
+		owner: owner,
+		weapon: weapon,
+		life: weapon.life,
 	}
 
-	// Add to list of missiles
-	missiles = append(missiles, &missile)
+	// Add to world's list of missiles
+	w.missiles = append(w.missiles, &missile)
 
 	return &missile
 }
@@ -94,12 +106,51 @@ func (m *Missile) ScreenPos() ScreenPos {
 
 func (m *Missile) Update() {
 	if !m.done {
+		switch m.weapon.behaviour {
+		case MissileHoming:
+			// Homing missiles fly until their fuel runs out rather than a set distance
+			m.Steer()
+			m.position.Add(m.movement)
+			m.EmitTrail()
+			m.life -= 1
+			if m.world.options.wrap {
+				WrapPosition(&m.position)
+				m.done = m.life <= 0
+			} else {
+				m.done = m.life <= 0 || m.position.X < -50 || m.position.X > ScreenWidth + 50 ||
+						m.position.Y < -50 || m.position.Y > ScreenHeight + 50
+			}
+			return
+		case MissileMine:
+			// Mines stay where they are dropped until they expire
+			m.life -= 1
+			m.done = m.life <= 0
+			return
+		case MissileBeam:
+			// A beam only hits on the tick it is fired then fades away
+			m.firing = m.life == m.weapon.life
+			m.life -= 1
+			m.done = m.life <= 0
+			return
+		}
 		m.position.Add(m.movement)
-		m.done =  m.position.X < -50 || m.position.X > ScreenWidth + 50 ||
-				m.position.Y < -50 || m.position.Y > ScreenHeight + 50
+		if m.world.options.wrap {
+			WrapPosition(&m.position)
+			m.travelled += m.movement.Length()
+			m.done = m.travelled > m.world.options.missileRange
+		} else {
+			m.done =  m.position.X < -50 || m.position.X > ScreenWidth + 50 ||
+					m.position.Y < -50 || m.position.Y > ScreenHeight + 50
+		}
 	}
 }
 
 func (m *Missile) Draw(screen *ebiten.Image) {
-	m.DrawImage(screen)
+	if m.weapon.behaviour == MissileBeam {
+		m.DrawBeam(screen)
+	} else if m.world.options.wrap {
+		m.DrawWrapped(screen)
+	} else {
+		m.DrawImage(screen)
+	}
 }
@@ -0,0 +1,595 @@
+// Network play
+// for Asteroids written in Go using Ebitengine
+// Two copies of the game play co-op over UDP in lockstep. Each copy sends the
+// input of its own player and only plays a tick once it has the input of both
+// players for it, so as the game is deterministic both copies play exactly the
+// same game. Input is played a few ticks after it is read to hide the time it
+// takes to arrive and is sent again until the other copy has it, as UDP can lose
+// packets. Checksums of the world are swapped every second to find the copies
+// going out of step.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"os"
+	"time"
+)
+
+var (
+	hostAddr = flag.String("host", "", "host a network co-op game listening on this address e.g. :7777")
+	joinAddr = flag.String("join", "", "join the network co-op game hosted at this address e.g. 127.0.0.1:7777")
+)
+
+const (
+	NetDelay = 4						// Ticks between reading the local input and playing it
+	NetMaxInputs = 64					// Most ticks of input sent in one packet
+	NetResendTime = 20 * time.Millisecond	// Gap before input is sent again when nothing new is read
+	NetHelloTime = 500 * time.Millisecond	// Gap between attempts to join
+	NetPingTime = time.Second
+	NetTimeout = 5 * time.Second		// Time without hearing from the other copy before it is lost
+	NetStallTime = 250 * time.Millisecond	// Time waiting for input before it is shown
+	ChecksumInterval = 60				// Ticks between checksums
+	DefaultNetAddress = ":7777"
+)
+
+// Kinds of packet
+const (
+	PacketHello = iota		// Sent by a player joining until the host starts a game
+	PacketStart				// Game number, seed, options and waves of a new game from the host
+	PacketInput				// Input of the sender's player from a tick
+	PacketChecksum			// Checksum of the sender's world at a tick
+	PacketPing
+	PacketPong
+	PacketLeave				// The sender has left the game
+)
+
+// Start of every packet followed by the protocol version
+var packetMagic = [] byte("ANET")
+
+const netVersion = 3
+
+// Packets larger than this are never sent
+const maxPacketSize = 1400
+
+// Longest waves text the host can send, leaving room in the start packet
+// for the options
+const maxNetWavesLength = 1000
+
+type NetSession struct {
+	conn		net.PacketConn
+	remote		net.Addr			// Other copy of the game, nil until a player joins the host
+	host		bool
+	player		int					// Player controlled from this copy
+	local		InputSource			// Input of the local player
+	received	chan netPacket
+	game		uint32				// Number of the game in play counting from 1
+	seed		uint64
+	options		Options
+	waves		[] WaveSpec			// Waves the host plays, sent so the other copy need not have the file
+	next		uint32				// Number of a game the host has started that has not begun here
+	nextSeed	uint64
+	nextOptions	Options
+	nextWaves	[] WaveSpec
+	startSeen	bool				// The other copy has begun the game in play
+	tick		int					// Next tick to play
+	sampled		bool				// The local input for the tick has been read
+	inputs		[MaxPlayers] [] InputState	// Input of each player for every tick so far
+	acked		int					// Ticks of local input the other copy has
+	checksums	[2] map[int] uint64	// Local and remote checksums waiting to be compared
+	desync		int					// First tick found out of step, -1 if none
+	ping		time.Duration		// Time for a packet to get there and back
+	left		bool				// The other copy has left
+	lastSent	time.Time
+	lastHello	time.Time
+	lastPing	time.Time
+	lastHeard	time.Time
+	lastTick	time.Time
+}
+
+type netPacket struct {
+	data	[] byte
+	from	net.Addr
+}
+
+// Host a game on address, the local player is the first player
+func NewHostSession(address string, local InputSource) (*NetSession, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewNetSession(conn, nil, local), nil
+}
+
+// Join the game hosted at address, the local player is the second player
+func NewJoinSession(address string, local InputSource) (*NetSession, error) {
+	remote, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return nil, err
+	}
+	return NewNetSession(conn, remote, local), nil
+}
+
+// Create a session sending and receiving on conn, with remote set it joins
+// the game hosted there, otherwise it hosts a game for the first to join
+func NewNetSession(conn net.PacketConn, remote net.Addr, local InputSource) *NetSession {
+	ns := &NetSession{
+		conn:		conn,
+		remote:		remote,
+		host:		remote == nil,
+		local:		local,
+		received:	make(chan netPacket, 256),
+		desync:		-1,
+	}
+	if !ns.host {
+		ns.player = 1
+	}
+	go ns.listen()
+	return ns
+}
+
+// Read packets until the connection is closed
+func (ns *NetSession) listen() {
+	for {
+		buf := make([] byte, maxPacketSize)
+		n, from, err := ns.conn.ReadFrom(buf)
+		if err != nil {
+			close(ns.received)
+			return
+		}
+		select {
+		case ns.received <- netPacket{data: buf[:n], from: from}:
+		default:
+			// Drop packets that arrive faster than they are handled, they are sent again
+		}
+	}
+}
+
+// Tell the other copy the game is being left and stop using the connection
+func (ns *NetSession) Close() {
+	if ns.remote != nil {
+		ns.send(ns.packet(PacketLeave))
+	}
+	ns.conn.Close()
+}
+
+// Input sources that play the input of each player for the tick in play
+func (ns *NetSession) Inputs() [] InputSource {
+	var inputs [] InputSource
+	for i := 0; i < MaxPlayers; i++ {
+		inputs = append(inputs, &NetInput{session: ns, player: i})
+	}
+	return inputs
+}
+
+// Input of one player played in step with the other copy
+type NetInput struct {
+	session	*NetSession
+	player	int
+}
+
+func (ni *NetInput) Read() InputState {
+	ns := ni.session
+	if ns.tick < len(ns.inputs[ni.player]) {
+		return ns.inputs[ni.player][ns.tick]
+	}
+	return InputState{}
+}
+
+// Check if a player has joined the host or the host has been found
+func (ns *NetSession) Connected() bool {
+	return ns.remote != nil && !ns.lastHeard.IsZero() && !ns.left
+}
+
+// Check if nothing has been heard from the other copy for too long
+func (ns *NetSession) Lost() bool {
+	return ns.left || (!ns.lastHeard.IsZero() && time.Since(ns.lastHeard) > NetTimeout)
+}
+
+// Check if the game has been waiting for the other copy long enough to show it
+func (ns *NetSession) Stalled() bool {
+	return time.Since(ns.lastTick) > NetStallTime
+}
+
+// Check the other copy has all the local input it needs to reach the tick in play
+// The host waits for this before starting another game so the other copy can finish
+func (ns *NetSession) Settled() bool {
+	return ns.acked >= ns.tick
+}
+
+// Begin a new game played with the seed, options and waves given
+// The host numbers the game and sends its start to the other copy
+func (ns *NetSession) Begin(seed uint64, options Options, waves [] WaveSpec) {
+	if ns.host {
+		ns.game++
+		ns.seed = seed
+		ns.options = options
+		ns.waves = waves
+		ns.startSeen = false
+		ns.sendStart()
+	}
+	ns.tick = 0
+	ns.sampled = false
+	ns.acked = 0
+	for i := range ns.inputs {
+		ns.inputs[i] = ns.inputs[i][:0]
+	}
+	// The local player's first ticks have no input while the first
+	// input read is on its way to the other copy
+	ns.inputs[ns.player] = append(ns.inputs[ns.player], make([] InputState, NetDelay)...)
+	ns.checksums = [2] map[int] uint64 {{}, {}}
+	ns.desync = -1
+	ns.lastTick = time.Now()
+}
+
+// Take a game started by the host, returning true if one is waiting to begin
+// The game's seed, options and waves are then in the session
+func (ns *NetSession) TakeStart() bool {
+	if ns.next <= ns.game {
+		return false
+	}
+	ns.game = ns.next
+	ns.seed = ns.nextSeed
+	ns.options = ns.nextOptions
+	ns.waves = ns.nextWaves
+	return true
+}
+
+// Handle packets that have arrived and keep the other copy up to date
+// Used while the game is not in play
+func (ns *NetSession) Update() {
+	ns.receive()
+	now := time.Now()
+	if !ns.host && ns.game == 0 && now.Sub(ns.lastHello) > NetHelloTime {
+		ns.lastHello = now
+		ns.send(ns.packet(PacketHello))
+	}
+	if ns.game > 0 && now.Sub(ns.lastSent) > NetResendTime {
+		ns.sendInputs()
+	}
+	if ns.Connected() && now.Sub(ns.lastPing) > NetPingTime {
+		ns.lastPing = now
+		ns.send(binary.AppendVarint(ns.packet(PacketPing), now.UnixNano()))
+	}
+}
+
+// Read the local input for the tick NetDelay ahead and check if the input of
+// both players has arrived for the tick in play
+// Returns false while the game must wait for the other copy
+func (ns *NetSession) Poll() bool {
+	if !ns.sampled {
+		ns.inputs[ns.player] = append(ns.inputs[ns.player], ns.local.Read())
+		ns.sampled = true
+		ns.sendInputs()
+	}
+	ns.Update()
+	for _, inputs := range ns.inputs {
+		if ns.tick >= len(inputs) {
+			return false
+		}
+	}
+	return true
+}
+
+// Move on to the next tick once it has been played in the world,
+// every ChecksumInterval ticks the world's checksum is sent to the other copy
+func (ns *NetSession) Advance(w *World) {
+	ns.tick++
+	ns.sampled = false
+	ns.lastTick = time.Now()
+	if ns.tick % ChecksumInterval == 0 {
+		sum := w.Checksum()
+		ns.checksums[0][ns.tick] = sum
+		buf := binary.AppendUvarint(ns.packet(PacketChecksum), uint64(ns.game))
+		buf = binary.AppendUvarint(buf, uint64(ns.tick))
+		ns.send(binary.AppendUvarint(buf, sum))
+		ns.compare(ns.tick)
+	}
+}
+
+// Compare the local and remote checksums of a tick once both are known
+func (ns *NetSession) compare(tick int) {
+	local, ok := ns.checksums[0][tick]
+	if !ok {
+		return
+	}
+	remote, ok := ns.checksums[1][tick]
+	if !ok {
+		return
+	}
+	if local != remote && ns.desync < 0 {
+		ns.desync = tick
+		fmt.Printf("Out of step with the other player at tick %d\n", tick)
+	}
+	delete(ns.checksums[0], tick)
+	delete(ns.checksums[1], tick)
+}
+
+// Start of a packet of the kind given
+func (ns *NetSession) packet(kind byte) [] byte {
+	buf := append([] byte {}, packetMagic...)
+	return append(buf, netVersion, kind)
+}
+
+// Send a packet to the other copy, lost packets are sent again later
+// so errors are ignored
+func (ns *NetSession) send(buf [] byte) {
+	if ns.remote != nil {
+		ns.conn.WriteTo(buf, ns.remote)
+	}
+}
+
+func (ns *NetSession) sendStart() {
+	// The other copy plays the waves sent rather than a file of its own
+	options := ns.options
+	options.waves = ""
+	text := options.String()
+	waves := WavesText(ns.waves)
+	buf := binary.AppendUvarint(ns.packet(PacketStart), uint64(ns.game))
+	buf = binary.AppendUvarint(buf, ns.seed)
+	buf = binary.AppendUvarint(buf, uint64(len(text)))
+	buf = append(buf, text...)
+	buf = binary.AppendUvarint(buf, uint64(len(waves)))
+	ns.send(append(buf, waves...))
+}
+
+// Send the local input the other copy does not have yet with the number
+// of ticks of its input received so far
+// The host sends the start with it until the other copy begins the game
+func (ns *NetSession) sendInputs() {
+	ns.lastSent = time.Now()
+	if ns.host && !ns.startSeen {
+		ns.sendStart()
+	}
+	other := ns.inputs[1 - ns.player]
+	buf := binary.AppendUvarint(ns.packet(PacketInput), uint64(ns.game))
+	buf = binary.AppendUvarint(buf, uint64(len(other)))
+	buf = binary.AppendUvarint(buf, uint64(ns.acked))
+	inputs := ns.inputs[ns.player][ns.acked:]
+	for _, in := range inputs[:min(len(inputs), NetMaxInputs)] {
+		next := AppendInputState(buf, in)
+		if len(next) > maxPacketSize {
+			break
+		}
+		buf = next
+	}
+	ns.send(buf)
+}
+
+// Handle every packet that has arrived
+func (ns *NetSession) receive() {
+	for {
+		select {
+		case p, ok := <-ns.received:
+			if !ok {
+				return
+			}
+			// Anything malformed or from someone else is dropped, stray
+			// packets can arrive from anywhere so are not reported
+			ns.handle(p)
+		default:
+			return
+		}
+	}
+}
+
+// Act on a packet from the other player, returning why it could not be used
+// Packets from anyone else are ignored
+func (ns *NetSession) handle(p netPacket) error {
+	header := len(packetMagic) + 2
+	if len(p.data) < header || !bytes.Equal(p.data[:len(packetMagic)], packetMagic) {
+		return errors.New("not a game packet")
+	}
+	if p.data[len(packetMagic)] != netVersion {
+		return fmt.Errorf("unsupported network version %d", p.data[len(packetMagic)])
+	}
+	kind := p.data[len(packetMagic) + 1]
+	if ns.remote == nil && ns.host && kind == PacketHello {
+		// The first player to say hello joins
+		ns.remote = p.from
+	}
+	if ns.remote == nil || !SameAddress(ns.remote, p.from) {
+		return nil
+	}
+	ns.lastHeard = time.Now()
+	r := bytes.NewReader(p.data[header:])
+	switch kind {
+	case PacketStart:
+		game, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		seed, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		length, err := binary.ReadUvarint(r)
+		if err != nil || length > uint64(r.Len()) {
+			return errors.New("corrupt start packet")
+		}
+		options := make([] byte, length)
+		r.Read(options)
+		length, err = binary.ReadUvarint(r)
+		if err != nil || length > uint64(r.Len()) {
+			return errors.New("corrupt start packet")
+		}
+		waves := make([] byte, length)
+		r.Read(waves)
+		if ns.host || uint32(game) <= max(ns.game, ns.next) {
+			return nil
+		}
+		if ns.nextOptions, err = ParseOptions(string(options)); err != nil {
+			return err
+		}
+		if ns.nextWaves, err = ReadWaves(bytes.NewReader(waves)); err != nil {
+			return err
+		}
+		ns.next = uint32(game)
+		ns.nextSeed = seed
+	case PacketInput:
+		game, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		acked, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		first, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		if uint32(game) != ns.game {
+			return nil
+		}
+		ns.startSeen = true
+		ns.acked = max(ns.acked, min(int(acked), len(ns.inputs[ns.player])))
+		other := 1 - ns.player
+		for tick := int(first); r.Len() > 0; tick++ {
+			in, err := ReadInputState(r)
+			if err != nil {
+				return err
+			}
+			if tick == len(ns.inputs[other]) {
+				ns.inputs[other] = append(ns.inputs[other], in)
+			}
+		}
+	case PacketChecksum:
+		game, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		tick, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		sum, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		if uint32(game) == ns.game {
+			ns.checksums[1][int(tick)] = sum
+			ns.compare(int(tick))
+		}
+	case PacketPing:
+		ns.send(append(ns.packet(PacketPong), p.data[header:]...))
+	case PacketPong:
+		sent, err := binary.ReadVarint(r)
+		if err != nil {
+			return err
+		}
+		ns.ping = time.Since(time.Unix(0, sent))
+	case PacketLeave:
+		ns.left = true
+	}
+	return nil
+}
+
+// Check if two addresses are the same host and port
+// A host listening on every interface sees IPv4 addresses in IPv6 form
+func SameAddress(a, b net.Addr) bool {
+	ua, okA := a.(*net.UDPAddr)
+	ub, okB := b.(*net.UDPAddr)
+	if okA && okB {
+		return ua.IP.Equal(ub.IP) && ua.Port == ub.Port
+	}
+	return a.String() == b.String()
+}
+
+// Hash of where everything that affects play is and the players' scores and lives
+// Copies of a game playing in step always have the same checksum
+func (w *World) Checksum() uint64 {
+	var buf [] byte
+	add := func(values ...float64) {
+		for _, v := range values {
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		}
+	}
+	for _, p := range w.players {
+		alive := 0.0
+		if p.alive {
+			alive = 1
+		}
+		add(p.position.X, p.position.Y, p.angle, float64(p.score), float64(p.lives), alive)
+	}
+	for _, m := range w.meteors {
+		add(m.position.X, m.position.Y, m.angle, float64(m.size))
+	}
+	for _, m := range w.missiles {
+		add(m.position.X, m.position.Y)
+	}
+	for _, s := range w.saucers {
+		add(s.position.X, s.position.Y)
+	}
+	for _, s := range w.saucerShots {
+		add(s.position.X, s.position.Y)
+	}
+	for _, p := range w.pickups {
+		add(p.position.X, p.position.Y, float64(p.kind))
+	}
+	h := fnv.New64a()
+	h.Write(buf)
+	return h.Sum64()
+}
+
+// Play a network game headless using the command line flags with the local
+// player's input from -script, printing the result and the world's checksum
+// at the end so the two copies can be compared
+func RunNetHeadless() {
+	local, err := LoadScript(*headlessScript)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	g := NewGame(nil, flagOptions, *seedFlag)
+	if err := g.JoinNetGame(*hostAddr + *joinAddr, *hostAddr != "", local); err != nil {
+		fmt.Println(err)
+		fmt.Println("Unable to start network game.")
+		os.Exit(1)
+	}
+	ns := g.net
+	for !g.Playing() && !ns.Lost() {
+		g.UpdateNetStart(false)
+		time.Sleep(time.Millisecond)
+	}
+	for g.ticks < *headlessTicks && g.Playing() {
+		ticks := g.ticks
+		if err := g.Update(); err != nil {
+			panic(err)
+		}
+		if g.ticks == ticks {
+			// Waiting for the other copy
+			if ns.Lost() {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	finished := g.ticks >= *headlessTicks || !g.Playing()
+	// Keep sending until the other copy has the input it needs to get this far
+	for deadline := time.Now().Add(NetTimeout); !ns.Settled() && !ns.Lost() && time.Now().Before(deadline); {
+		ns.Update()
+		time.Sleep(time.Millisecond)
+	}
+	fmt.Println(g.Result())
+	fmt.Printf("checksum: %016x\n", g.world.Checksum())
+	ns.Close()
+	if !finished {
+		fmt.Println("Lost the connection to the other player")
+		os.Exit(1)
+	}
+	if ns.desync >= 0 {
+		os.Exit(1)
+	}
+}
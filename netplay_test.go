@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Scripted input for one player of a network game
+func netScript(t *testing.T, script string) InputSource {
+	t.Helper()
+	si, err := ParseScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return si
+}
+
+// Play the host's and joiner's games side by side until both have played
+// ticks or ended, then wait for both to have the other's input and checksums
+func playNetGames(t *testing.T, host, join *Game, ticks int) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	waitFor := func(what string) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s at ticks %d and %d", what, host.ticks, join.ticks)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for !host.Playing() || !join.Playing() {
+		for _, g := range [] *Game {host, join} {
+			if !g.Playing() {
+				g.UpdateNetStart(false)
+			}
+		}
+		waitFor("the games to start")
+	}
+	playing := func(g *Game) bool {
+		return g.Playing() && g.ticks < ticks
+	}
+	for playing(host) || playing(join) {
+		played := false
+		for _, g := range [] *Game {host, join} {
+			if playing(g) {
+				before := g.ticks
+				if err := g.Update(); err != nil {
+					t.Fatal(err)
+				}
+				played = played || g.ticks > before
+			}
+		}
+		if !played {
+			waitFor("the other player's input")
+		}
+	}
+	for !host.net.Settled() || !join.net.Settled() {
+		host.net.Update()
+		join.net.Update()
+		waitFor("the input to be acknowledged")
+	}
+}
+
+func TestNetGameStaysInStep(t *testing.T) {
+	host := NewGame(nil, DefaultOptions(), 42)
+	if err := host.JoinNetGame("127.0.0.1:0", true, netScript(t, "20000 left fire")); err != nil {
+		t.Skip("unable to host over UDP:", err)
+	}
+	defer host.net.Close()
+	join := NewGame(nil, DefaultOptions(), 0)
+	address := host.net.conn.LocalAddr().String()
+	if err := join.JoinNetGame(address, false, netScript(t, "90 thrust fire\n20000 right fire")); err != nil {
+		t.Fatal(err)
+	}
+	defer join.net.Close()
+
+	playNetGames(t, host, join, 600)
+	if host.ticks != join.ticks {
+		t.Errorf("host played %d ticks but the joiner played %d", host.ticks, join.ticks)
+	}
+	if host.net.desync != -1 || join.net.desync != -1 {
+		t.Errorf("out of step from tick %d on the host and %d on the joiner", host.net.desync, join.net.desync)
+	}
+	if a, b := host.world.Checksum(), join.world.Checksum(); a != b {
+		t.Errorf("host checksum %016x but joiner checksum %016x", a, b)
+	}
+}
+
+func TestNetGamePlaysHostWaves(t *testing.T) {
+	if text := WavesText(LoadWaves("")); len(text) > maxNetWavesLength {
+		t.Fatalf("built in waves are %d characters, too long to send", len(text))
+	}
+	// Only the host reads the waves file
+	name := t.TempDir() + "/waves.txt"
+	if err := os.WriteFile(name, [] byte("tiny=6 speed=2\nsmall=2 extra=3 gap=0.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultOptions()
+	options.waves = name
+	host := NewGame(nil, options, 9)
+	if err := host.JoinNetGame("127.0.0.1:0", true, netScript(t, "20000 left fire")); err != nil {
+		t.Skip("unable to host over UDP:", err)
+	}
+	defer host.net.Close()
+	// Gone once the host has read it as if the joiner were on another machine
+	os.Remove(name)
+	join := NewGame(nil, DefaultOptions(), 0)
+	if err := join.JoinNetGame(host.net.conn.LocalAddr().String(), false, netScript(t, "20000 right fire")); err != nil {
+		t.Fatal(err)
+	}
+	defer join.net.Close()
+
+	playNetGames(t, host, join, 300)
+	if len(host.waves) != 2 || !reflect.DeepEqual(join.waves, host.waves) {
+		t.Fatalf("host played %v but the joiner played %v", host.waves, join.waves)
+	}
+	if host.net.desync != -1 || join.net.desync != -1 {
+		t.Errorf("out of step from tick %d on the host and %d on the joiner", host.net.desync, join.net.desync)
+	}
+}
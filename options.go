@@ -0,0 +1,191 @@
+// Game options
+// for Asteroids written in Go using Ebitengine
+// Options change how the game plays so they are set from the command line
+// and saved in replays so a recording always plays back the same way.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ship physics models
+const (
+	PhysicsArcade = 0		// Ship moves in the direction it points and coasts to a stop
+	PhysicsNewtonian = 1	// Thrust accelerates the ship which keeps its momentum
+)
+
+var physicsNames = [] string {"arcade", "newtonian"}
+
+type Options struct {
+	physics		int
+	topSpeed	float64		// Fastest the ship can travel in pixels per tick (newtonian)
+	thrust		float64		// Acceleration while thrusting in pixels per tick per tick (newtonian)
+	drag		float64		// Fraction of speed lost each tick (newtonian)
+	wrap		bool		// Meteors and missiles wrap around the screen edges
+	missileRange	float64	// Distance a missile travels before it is gone when wrapping
+	waves		string		// File of waves to play, empty for the built in waves
+	meteorCollide	bool	// Meteors bounce off each other
+	procedural	bool		// Meteors are generated jagged rocks instead of the images
+	coop		bool		// Two players on one keyboard share the asteroid field
+	versus		bool		// Two players on one keyboard shoot each other
+	kills		int			// Kills needed to win in versus
+}
+
+func DefaultOptions() Options {
+	return Options{
+		physics:	PhysicsArcade,
+		topSpeed:	6,
+		thrust:		0.1,
+		drag:		0.005,
+		wrap:		false,
+		missileRange:	600,
+		waves:		"",
+		meteorCollide:	false,
+		procedural:	false,
+		coop:		false,
+		versus:		false,
+		kills:		5,
+	}
+}
+
+// Options set on the command line
+var flagOptions = DefaultOptions()
+
+func init() {
+	flag.Func("physics", "ship physics: arcade or newtonian", func(s string) error {
+		return flagOptions.Set("physics", s)
+	})
+	flag.Func("topspeed", "top speed of the ship with newtonian physics (default 6)", func(s string) error {
+		return flagOptions.Set("topspeed", s)
+	})
+	flag.Func("thrust", "acceleration of the ship with newtonian physics (default 0.1)", func(s string) error {
+		return flagOptions.Set("thrust", s)
+	})
+	flag.Func("drag", "fraction of speed lost each tick with newtonian physics (default 0.005)", func(s string) error {
+		return flagOptions.Set("drag", s)
+	})
+	flag.BoolVar(&flagOptions.wrap, "wrap", flagOptions.wrap, "meteors and missiles wrap around the screen edges")
+	flag.Float64Var(&flagOptions.missileRange, "missilerange", flagOptions.missileRange, "distance missiles travel when wrapping")
+	flag.BoolVar(&flagOptions.meteorCollide, "meteorcollide", flagOptions.meteorCollide, "meteors bounce off each other")
+	flag.BoolVar(&flagOptions.procedural, "procedural", flagOptions.procedural, "meteors are generated jagged rocks that break into pieces")
+	flag.BoolFunc("coop", "two players on one keyboard share the asteroid field", func(s string) error {
+		return flagOptions.Set("coop", s)
+	})
+	flag.BoolFunc("versus", "two players on one keyboard shoot each other", func(s string) error {
+		return flagOptions.Set("versus", s)
+	})
+	flag.Func("kills", "kills needed to win in versus (default 5)", func(s string) error {
+		return flagOptions.Set("kills", s)
+	})
+	flag.StringVar(&flagOptions.waves, "waves", flagOptions.waves, "file of asteroid waves to play instead of the built in waves")
+}
+
+// Set the option called name from its text value
+func (o *Options) Set(name, value string) error {
+	var err error
+	switch name {
+	case "physics":
+		physics := -1
+		for i, n := range physicsNames {
+			if strings.EqualFold(n, value) {
+				physics = i
+			}
+		}
+		if physics < 0 {
+			err = fmt.Errorf("unknown physics %q", value)
+		} else {
+			o.physics = physics
+		}
+	case "topspeed":
+		o.topSpeed, err = strconv.ParseFloat(value, 64)
+		// Written so NaN is rejected too
+		if err == nil && !(o.topSpeed > 0) {
+			err = fmt.Errorf("topspeed must be more than 0")
+		}
+	case "thrust":
+		o.thrust, err = strconv.ParseFloat(value, 64)
+		if err == nil && !(o.thrust >= 0) {
+			err = fmt.Errorf("thrust must be at least 0")
+		}
+	case "drag":
+		o.drag, err = strconv.ParseFloat(value, 64)
+		if err == nil && !(o.drag >= 0 && o.drag < 1) {
+			err = fmt.Errorf("drag must be at least 0 and less than 1")
+		}
+	case "wrap":
+		o.wrap, err = strconv.ParseBool(value)
+	case "missilerange":
+		o.missileRange, err = strconv.ParseFloat(value, 64)
+	case "waves":
+		o.waves = value
+	case "meteorcollide":
+		o.meteorCollide, err = strconv.ParseBool(value)
+	case "procedural":
+		o.procedural, err = strconv.ParseBool(value)
+	case "coop":
+		o.coop, err = strconv.ParseBool(value)
+		if err == nil && o.coop && o.versus {
+			err = fmt.Errorf("coop and versus cannot both be on")
+		}
+	case "versus":
+		o.versus, err = strconv.ParseBool(value)
+		if err == nil && o.coop && o.versus {
+			err = fmt.Errorf("coop and versus cannot both be on")
+		}
+	case "kills":
+		o.kills, err = strconv.Atoi(value)
+		if err == nil && o.kills < 1 {
+			err = fmt.Errorf("kills must be at least 1")
+		}
+	default:
+		err = fmt.Errorf("unknown option %q", name)
+	}
+	return err
+}
+
+// Options as name=value pairs separated by spaces
+// The waves file name is quoted as it may contain spaces
+func (o Options) String() string {
+	return fmt.Sprintf("physics=%s topspeed=%v thrust=%v drag=%v wrap=%t missilerange=%v meteorcollide=%t procedural=%t coop=%t versus=%t kills=%d waves=%q",
+		physicsNames[o.physics], o.topSpeed, o.thrust, o.drag, o.wrap, o.missileRange, o.meteorCollide, o.procedural,
+		o.coop, o.versus, o.kills, o.waves)
+}
+
+// Number of players in each game
+func (o Options) Players() int {
+	if o.coop || o.versus {
+		return 2
+	}
+	return 1
+}
+
+// Read options written by String, any not given keep their default
+// A value starting with a quote runs to the closing quote
+func ParseOptions(s string) (Options, error) {
+	o := DefaultOptions()
+	for rest := strings.TrimSpace(s); rest != ""; rest = strings.TrimSpace(rest) {
+		name, value, found := strings.Cut(rest, "=")
+		if !found || strings.ContainsAny(name, " \t\n") {
+			field, _, _ := strings.Cut(rest, " ")
+			return o, fmt.Errorf("option %q has no value", field)
+		}
+		if strings.HasPrefix(value, "\"") {
+			quoted, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return o, fmt.Errorf("option %s has an unfinished quoted value", name)
+			}
+			rest = value[len(quoted):]
+			value, _ = strconv.Unquote(quoted)
+		} else {
+			value, rest, _ = strings.Cut(value, " ")
+		}
+		if err := o.Set(name, value); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestOptionsRoundTrip(t *testing.T) {
+	o := DefaultOptions()
+	o.physics = PhysicsNewtonian
+	o.drag = 0.0125
+	o.wrap = true
+	o.versus = true
+	o.kills = 3
+	o.waves = `my waves/"hard" one.txt`
+	read, err := ParseOptions(o.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != o {
+		t.Errorf("wrote %v read %v", o, read)
+	}
+}
+
+func TestParseOptionsRejectsBadText(t *testing.T) {
+	for _, s := range [] string {
+		"wrap",
+		"physics=arcade wrap",
+		`waves="unfinished`,
+		"kills=x",
+		"kills=0",
+		"kills=-2",
+		"topspeed=0",
+		"topspeed=-1",
+		"topspeed=NaN",
+		"thrust=-0.1",
+		"drag=-0.01",
+		"drag=1",
+		"drag=NaN",
+		"coop=true versus=true",
+		"versus=true coop=true",
+		"colour=red",
+	} {
+		if _, err := ParseOptions(s); err == nil {
+			t.Errorf("%q was accepted", s)
+		}
+	}
+}
+
+func TestParseOptionsAcceptsLimits(t *testing.T) {
+	for _, s := range [] string {
+		"thrust=0",
+		"drag=0",
+		"drag=0.99",
+		"topspeed=0.5",
+		"kills=1",
+	} {
+		if _, err := ParseOptions(s); err != nil {
+			t.Errorf("%q was rejected: %v", s, err)
+		}
+	}
+}
+
+func TestCoopAndVersusFlags(t *testing.T) {
+	saved := flagOptions
+	defer func() { flagOptions = saved }()
+	for _, test := range [] struct {
+		args	[] string
+		ok		bool
+	}{
+		{[] string {"-coop"}, true},
+		{[] string {"-versus", "-kills", "3"}, true},
+		{[] string {"-coop", "-versus"}, false},
+		{[] string {"-versus", "-coop=true"}, false},
+		{[] string {"-coop", "-coop=false", "-versus"}, true},
+	} {
+		flagOptions = DefaultOptions()
+		fs := flag.NewFlagSet("asteroids", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		flag.VisitAll(func(f *flag.Flag) {
+			fs.Var(f.Value, f.Name, f.Usage)
+		})
+		if err := fs.Parse(test.args); (err == nil) != test.ok {
+			t.Errorf("%v gave error %v", test.args, err)
+		}
+	}
+}
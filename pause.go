@@ -0,0 +1,70 @@
+// Pause menu
+// for Asteroids written in Go using Ebitengine
+// Pausing freezes everything in the world, including its timers,
+// until the game is resumed, restarted or abandoned.
+
+package main
+
+const (
+	MenuResume = iota
+	MenuRestart
+	MenuSettings
+	MenuQuit
+	NumMenuItems
+)
+
+var menuItems = [NumMenuItems] string {"Resume", "Restart", "Settings", "Quit to title"}
+
+type PauseMenu struct {
+	selected	int
+}
+
+// Move the selection up or down the menu wrapping at the ends
+func (pm *PauseMenu) Move(step int) {
+	pm.selected = (pm.selected + step + NumMenuItems) % NumMenuItems
+}
+
+// Pause the game in play
+func (g *Game) Pause() {
+	g.game_mode = Paused
+	g.pauseMenu.selected = MenuResume
+	g.world.timers.Pause()
+}
+
+// Resume the paused game
+func (g *Game) Resume() {
+	g.game_mode = InPlay
+	g.world.timers.Resume()
+}
+
+// Process the menu while paused, pressed reports actions pressed this tick
+// Thrust and reverse move up and down the menu, fire or start selects
+// and pause resumes the game
+func (g *Game) UpdatePauseMenu(pressed func(Action) bool) {
+	switch {
+	case pressed(ActionPause):
+		g.Resume()
+	case pressed(ActionThrust):
+		g.pauseMenu.Move(-1)
+	case pressed(ActionReverse):
+		g.pauseMenu.Move(1)
+	case pressed(ActionFire) || pressed(ActionStart):
+		switch g.pauseMenu.selected {
+		case MenuResume:
+			g.Resume()
+		case MenuRestart:
+			if g.replay != nil {
+				// Play the recording again from the start
+				g.inputs = g.replay.Inputs()
+			}
+			g.Start()
+		case MenuSettings:
+			if g.controls != nil {
+				g.controlsReturn = Paused
+				g.game_mode = Controls
+			}
+		case MenuQuit:
+			g.Quit()
+		}
+	}
+}
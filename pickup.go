@@ -0,0 +1,178 @@
+// Power-up pickup struct and methods
+// for Asteroids written in Go using Ebitengine
+// Destroyed asteroids sometimes leave a pickup that drifts slowly until it is
+// collected by flying into it or expires after a few seconds.
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/paul63/vector2"
+)
+
+// Kinds of pickup
+const (
+	PickupShield = iota
+	PickupRapidFire
+	PickupSpread
+	PickupExtraLife
+	PickupBomb
+	PickupSlowTime
+	PickupAmmo
+	NumPickups
+)
+
+const (
+	PickupChance = 0.1		// Chance of a destroyed asteroid leaving a pickup
+	PickupLife = 480		// Ticks before a pickup expires
+	MaxLives = 5			// Extra life pickups never give more lives than this
+)
+
+var (
+	pickupSprite = LoadSprite("assets/pickup.png")
+	pickupLetters = [NumPickups] string {"S", "R", "W", "+", "B", "T", "A"}
+	pickupColors = [NumPickups] color.Color {
+		color.RGBA{80, 180, 255, 255},
+		color.RGBA{255, 200, 0, 255},
+		color.RGBA{0, 255, 120, 255},
+		color.RGBA{255, 80, 80, 255},
+		color.RGBA{255, 120, 0, 255},
+		color.RGBA{200, 120, 255, 255},
+		color.RGBA{255, 255, 255, 255},
+	}
+)
+
+func (w *World) UpdateAllPickups(){
+	for _, p := range w.pickups {
+		p.Update()
+	}
+}
+
+func (w *World) DrawAllPickups(screen *ebiten.Image){
+	for _, p := range w.pickups {
+		p.Draw(screen)
+	}
+}
+
+func (w *World) ClearDonePickups(){
+	for i, p := range(w.pickups){
+		if p.done{
+			w.pickups = append(w.pickups[:i], w.pickups[i+1:]...)
+			break
+		}
+	}
+}
+
+func (w *World) ClearAllPickups(){
+	w.pickups = nil
+}
+
+type Pickup struct {
+	GameSprite
+	world	*World
+	kind	int
+	life	int		// Ticks left before it expires
+}
+
+// Sometimes leave a pickup of a random kind at x, y
+func DropPickup(w *World, x, y float64) {
+	if w.rng.Float64() < PickupChance {
+		NewPickup(w, w.rng.IntN(NumPickups), x, y)
+	}
+}
+
+// Create a new pickup of kind drifting from x, y and add to the world's list
+func NewPickup(w *World, kind int, x, y float64) *Pickup {
+	angle := w.rng.Float64() * 2 * math.Pi
+	speed := 0.3 + w.rng.Float64() * 0.5
+	movement := vector2.Vector{
+		X: math.Sin(angle) * speed,
+		Y: math.Cos(angle) * -speed,
+	}
+	pickup := Pickup{
+		GameSprite: NewGameSprite(pickupSprite, vector2.Vector{X: x, Y: y}, movement, 0),
+		world:		w,
+		kind:		kind,
+		life:		PickupLife,
+	}
+	// Add to world's list of pickups
+	w.pickups = append(w.pickups, &pickup)
+
+	return &pickup
+}
+
+func (p *Pickup) Update() {
+	if !p.done {
+		p.position.Add(p.movement)
+		WrapPosition(&p.position)
+		p.life -= 1
+		p.done = p.life <= 0
+	}
+}
+
+// Draw the pickup in its colour with its letter, flashing as it is about to expire
+func (p *Pickup) Draw(screen *ebiten.Image) {
+	if p.done || (p.life < 120 && p.life / 8 % 2 == 1) {
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(p.width / 2), -float64(p.height / 2))
+	op.GeoM.Translate(p.position.X, p.position.Y)
+	op.ColorScale.ScaleWithColor(pickupColors[p.kind])
+	screen.DrawImage(p.sprite.Image(), op)
+
+	top := &text.DrawOptions{}
+	top.GeoM.Translate(p.position.X, p.position.Y)
+	top.PrimaryAlign = text.AlignCenter
+	top.SecondaryAlign = text.AlignCenter
+	top.ColorScale.ScaleWithColor(white)
+	text.Draw(screen, pickupLetters[p.kind], &text.GoTextFace{
+		Source: scoreFace,
+		Size:   14,
+	}, top)
+}
+
+// Give the player who collected the pickup what it holds
+func (g *Game) Collect(p *Pickup, player *Player) {
+	p.done = true
+	switch p.kind {
+	case PickupShield:
+		player.AddEffect(EffectShield)
+	case PickupRapidFire:
+		player.AddEffect(EffectRapidFire)
+	case PickupSpread:
+		player.AddEffect(EffectSpread)
+	case PickupSlowTime:
+		player.AddEffect(EffectSlowTime)
+	case PickupExtraLife:
+		player.lives = min(player.lives + 1, MaxLives)
+	case PickupBomb:
+		player.score += g.world.Bomb()
+	case PickupAmmo:
+		player.AddAmmo()
+	}
+}
+
+// Destroy every meteor, saucer and saucer shot without any fragments
+// and return the score for them
+func (w *World) Bomb() int {
+	score := 0
+	for _, m := range w.meteors {
+		if !m.done {
+			score += scores[m.size]
+			m.done = true
+			NewExplosion(w, m.position.X, m.position.Y, 20 * (m.size + 1), expColor, 0.04)
+		}
+	}
+	for _, s := range w.saucers {
+		score += s.Hit(true)
+	}
+	for _, s := range w.saucerShots {
+		s.done = true
+	}
+	return score
+}
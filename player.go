@@ -8,7 +8,6 @@ package main
 import (
 	"image/color"
 	"math"
-	"math/rand/v2"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/paul63/vector2"
@@ -17,41 +16,89 @@ import (
 const (
 	MaxThrust = 30
 	GapTimer = 30
+	MaxPlayers = 2
+	StartLives = 3
+	RespawnTime = 3			// Seconds before a player who has been hit comes back
 )
 
 var (
-	playerSprite = LoadImage("assets/player.png")
-	playerSpriteThrust = LoadImage("assets/thrust.png")
-	reloadTimer = 0
+	playerSprite = LoadSprite("assets/player.png")
+	playerSpriteThrust = LoadSprite("assets/thrust.png")
+	// The second player's ship is a different colour
+	player2Sprite = LoadSprite("assets/player2.png")
+	player2SpriteThrust = LoadSprite("assets/thrust2.png")
 	reloadTime = 15
 	rotationSpeed = math.Pi / float64(ebiten.TPS())
 )
 
 type Player struct {
 	GameSprite
+	world	*World
+	number	int				// 0 for the first player and 1 for the second
 	loaded	bool			
 	alive	bool
+	score	int
+	lives	int					// Ships left including the one in play
+	kills	int					// Times the other player has been shot down in versus
+	killedBy	*Player			// Player whose missile destroyed the ship, nil for anything else
+	respawnTimer	*Timer		// Started when the player is hit
 	thrust	float64
 	hyperJumpTimer	int
 	reverseTimer 	int
+	reloadTimer		int
+	effects			[NumEffects] int	// Ticks left of each effect from pickups
+	deflectorEnergy	float64
+	deflecting		bool				// Deflector shield is up
+	weapon			int					// Weapon fired
+	ammo			[NumWeapons] int	// Shots left for each weapon
+	switchHeld		bool				// Next or previous weapon held last tick
 }
 
-func NewPlayer() *Player {
-	sprite := playerSprite
-	pos := vector2.Vector{
-		X: ScreenWidth/2,
-		Y: ScreenHeight/2,
-	}
-
-	gameSprite := NewGameSprite(sprite, pos, vector2.Vector{X:0, Y:0}, 0)
-
-	return &Player{
-		GameSprite: gameSprite,
+// Create the next player and add to the world's list of players
+func NewPlayer(w *World) *Player {
+	number := len(w.players)
+	player := &Player{
+		world:	  w,
+		number:	  number,
 		loaded:   true,
 		alive:	  true,
 		thrust:	  0,		
+		lives:	  StartLives,
+		respawnTimer: NewTimer(w, RespawnTime, false),
 		hyperJumpTimer: 0,
 		reverseTimer: 0,	
+		reloadTimer: 0,
+		deflectorEnergy: MaxDeflectorEnergy,
+	}
+	player.GameSprite = NewGameSprite(player.ShipSprite(false), player.StartPosition(),
+		vector2.Vector{X:0, Y:0}, 0)
+	// Only started when the player is hit
+	player.respawnTimer.Stop()
+	// Add to world's list of players
+	w.players = append(w.players, player)
+
+	return player
+}
+
+// Image of the player's ship with or without its engine firing
+func (p *Player) ShipSprite(thrusting bool) *SpriteImage {
+	switch {
+	case p.number == 1 && thrusting:
+		return player2SpriteThrust
+	case p.number == 1:
+		return player2Sprite
+	case thrusting:
+		return playerSpriteThrust
+	}
+	return playerSprite
+}
+
+// Where the player appears, players are spread evenly across the middle of the screen
+func (p *Player) StartPosition() vector2.Vector {
+	players := p.world.options.Players()
+	return vector2.Vector{
+		X: float64(ScreenWidth * (p.number + 1) / (players + 1)),
+		Y: ScreenHeight/2,
 	}
 }
 
@@ -65,37 +112,54 @@ func (p *Player) ScreenPos() ScreenPos {
 
 }
 
+// Fire the current weapon, changing back to the standard weapon when it runs out
 func (p *Player) LaunchMissile() {
-	NewMissile(p.position, p.angle)
+	weapon := p.Weapon()
+	shots := weapon.shots
+	if p.HasEffect(EffectSpread) {
+		shots += 2
+	}
+	for i := 0; i < shots; i++ {
+		angle := p.angle + (float64(i) - float64(shots - 1) / 2) * SpreadAngle
+		NewMissile(p.world, p, weapon, p.position, angle)
+	}
+	if weapon.ammo != Unlimited {
+		p.ammo[p.weapon] -= 1
+		if p.ammo[p.weapon] <= 0 {
+			p.weapon = WeaponStandard
+		}
+	}
 	p.loaded = false
-	reloadTimer = reloadTime
+	p.reloadTimer = p.ReloadTime()
 }
 
-func (p *Player) Update() {
+// Distance the ship moves each tick, with arcade physics movement is
+// only the way the ship faces
+func (p *Player) Velocity() vector2.Vector {
+	if p.world.options.physics == PhysicsNewtonian {
+		return p.movement
+	}
+	return vector2.Vector{X: p.movement.X * p.thrust / 5, Y: p.movement.Y * p.thrust / 5}
+}
+
+// Move the player using the input read for this tick
+func (p *Player) Update(in InputState) {
 	// check if need to move player
-	if p.thrust > 0 {
+	if p.world.options.physics == PhysicsNewtonian {
+		p.MoveNewtonian(in)
+	} else if p.thrust > 0 {
 		p.position.X += p.movement.X * p.thrust / 5
 		p.position.Y += p.movement.Y * p.thrust / 5
 		p.thrust -= 1
-		if p.thrust == 0 {
-			p.sprite = playerSprite
-		}
-		if p.position.X > ScreenWidth {
-			p.position.X = 0
-		} else {
-			if p.position.X < 0 {
-				p.position.X = ScreenWidth
-			}
-		}
-		if p.position.Y > ScreenHeight{
-			p.position.Y = 0
-		} else {
-			if p.position.Y < 0 {
-				p.position.Y = ScreenHeight
-			}
+		if p.thrust <= 0 {
+			p.thrust = 0
+			p.sprite = p.ShipSprite(false)
 		}
+		p.Wrap()
 	}
 
+	p.UpdateEffects()
+
 	// Update gap timers
 	if p.hyperJumpTimer > 0 {
 		p.hyperJumpTimer -= 1
@@ -103,72 +167,142 @@ func (p *Player) Update() {
 	if p.reverseTimer > 0 {
 		p.reverseTimer -= 1
 	}
-	if reloadTimer > 0{
-		reloadTimer -= 1
-		if reloadTimer == 0 {
+	if p.reloadTimer > 0{
+		p.reloadTimer -= 1
+		if p.reloadTimer == 0 {
 			p.loaded = true
 		}
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
+	if in.Held(ActionRotateLeft) {
 		// rotate left
 		p.angle -= rotationSpeed
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyRight) {
+	if in.Held(ActionRotateRight) {
 		// rotate right
 		p.angle += rotationSpeed
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) && p.reverseTimer <= 0 {
+	if in.turn != 0 {
+		// rotate by the amount the stick is pushed
+		p.angle += rotationSpeed * float64(in.turn) / 100
+	}
+	if in.Held(ActionReverse) && p.reverseTimer <= 0 {
 		// reverse direction
 		p.angle += 1.5708 * 2
 		p.reverseTimer = GapTimer
 	}
-	if ebiten.IsKeyPressed(ebiten.KeySpace) && p.loaded {
+	if in.Held(ActionFire) && p.loaded {
 		// fire missile
 		p.LaunchMissile()
 	}
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButton0) && p.loaded {
+	if in.Held(ActionAimFire) && p.loaded {
         // Set player to point in direction of mouse cursor and fire missile
-		x, y := ebiten.CursorPosition()
-		p.angle = p.position.PointTowards(vector2.Vector{X: float64(x), Y: float64(y)})
+		p.angle = p.position.PointTowards(vector2.Vector{X: float64(in.cursorX), Y: float64(in.cursorY)})
 		p.LaunchMissile()
 	}
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButton2) || ebiten.IsKeyPressed(ebiten.KeyArrowUp){
+	if p.world.options.physics == PhysicsArcade && p.ThrustPower(in) > 0 {
 		// Set player movement in progress
-		p.thrust = MaxThrust
-		p.sprite = playerSpriteThrust
+		p.thrust = math.Max(p.thrust, MaxThrust * p.ThrustPower(in))
+		p.sprite = p.ShipSprite(true)
 		// Calculate a target so flies in direction ship pointing
 		p.movement = vector2.Vector{
 			X: math.Sin(p.angle),
 			Y: math.Cos(p.angle) * -1,
 		}
 	}
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButton1) || ebiten.IsKeyPressed(ebiten.KeyH){
+	p.UpdateDeflector(in)
+	p.UpdateWeapon(in)
+	if in.Held(ActionHyperjump) {
 		// Perform hyperjump
 		if p.hyperJumpTimer <= 0 {
 			p.hyperJumpTimer = GapTimer
-			p.position.X = float64(rand.IntN(ScreenWidth - 80) + 40)
-			p.position.Y = float64(rand.IntN(ScreenHeight - 80) + 40)
+			p.position.X = float64(p.world.rng.IntN(ScreenWidth - 80) + 40)
+			p.position.Y = float64(p.world.rng.IntN(ScreenHeight - 80) + 40)
+		}
+	}
+}
+
+// How hard the player is thrusting from 0 to 1
+// a stick only gives full thrust when pushed all the way
+func (p *Player) ThrustPower(in InputState) float64 {
+	if in.Held(ActionThrust) {
+		return 1
+	}
+	return float64(in.push) / 100
+}
+
+// Thrust accelerates the ship in the direction it points while drag slowly
+// reduces its speed. Rotating never changes the direction it is travelling.
+func (p *Player) MoveNewtonian(in InputState) {
+	opts := p.world.options
+	power := p.ThrustPower(in)
+	if power > 0 {
+		p.movement.X += math.Sin(p.angle) * opts.thrust * power
+		p.movement.Y -= math.Cos(p.angle) * opts.thrust * power
+		p.sprite = p.ShipSprite(true)
+	} else {
+		p.sprite = p.ShipSprite(false)
+	}
+	p.movement.X *= 1 - opts.drag
+	p.movement.Y *= 1 - opts.drag
+	speed := math.Hypot(p.movement.X, p.movement.Y)
+	if speed > opts.topSpeed {
+		p.movement.X *= opts.topSpeed / speed
+		p.movement.Y *= opts.topSpeed / speed
+	}
+	p.position.Add(p.movement)
+	p.Wrap()
+}
+
+// Move to the opposite edge if the player has gone off the screen
+func (p *Player) Wrap() {
+	if p.position.X > ScreenWidth {
+		p.position.X = 0
+	} else {
+		if p.position.X < 0 {
+			p.position.X = ScreenWidth
+		}
+	}
+	if p.position.Y > ScreenHeight{
+		p.position.Y = 0
+	} else {
+		if p.position.Y < 0 {
+			p.position.Y = ScreenHeight
 		}
 	}
 }
 
 func (p *Player) Draw(screen *ebiten.Image) {
 	if p.alive{
-		p.DrawImage(screen)
+		if p.world.options.wrap {
+			p.DrawWrapped(screen)
+		} else {
+			p.DrawImage(screen)
+		}
 	}
 }
 
+// Destroy the ship and wait before the player can come back
 func (p *Player) Hit(){
 	p.alive = false
-	NewExplosion(p.position.X, p.position.Y, 75, 
+	p.deflecting = false
+	p.respawnTimer.ChangeTime(RespawnTime, false)
+	p.ClearEffects()
+	NewExplosion(p.world, p.position.X, p.position.Y, 75, 
 		color.RGBA{255, 0, 0, 100}, 0.025)
 }
 
 func (p *Player) Reset() {
-	p.position.X = ScreenWidth/2
-	p.position.Y = ScreenHeight/2
+	p.position = p.StartPosition()
 	p.angle = 0
 	p.loaded = true
 	p.alive = true
+	p.killedBy = nil
 	p.thrust = 0
+	p.movement = vector2.Vector{X: 0, Y: 0}
+	p.sprite = p.ShipSprite(false)
+	p.hyperJumpTimer = 0
+	p.reverseTimer = 0
+	p.deflectorEnergy = MaxDeflectorEnergy
+	p.deflecting = false
+	p.ClearEffects()
 }
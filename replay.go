@@ -0,0 +1,369 @@
+// Input recording and replay
+// for Asteroids written in Go using Ebitengine
+// A replay holds the options, waves, seed and the input of every tick of a
+// game for each player so it can be played back exactly and checked against
+// the recorded score.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	recordFile = flag.String("record", "", "record each game to this replay file")
+	replayFile = flag.String("replay", "", "play back this replay file and check the score")
+)
+
+// Start of every replay file followed by the format version
+var replayMagic = [] byte("AREP")
+
+const replayVersion = 10
+
+// Longest waves text read from a replay file
+const maxWavesLength = 1 << 20
+
+type Replay struct {
+	options	Options
+	waves	[] WaveSpec		// Waves played, kept as the waves file may change or be missing
+	seed	uint64
+	score	int
+	ended	bool		// False if the game was quit or stopped before it ended
+	states	[] [] InputState	// Input of every tick for each player
+}
+
+// Write the replay to w
+// Each player's input follows the last and identical ticks are stored
+// as a single run to keep the file small
+func (r *Replay) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.Write(replayMagic)
+	buf.WriteByte(replayVersion)
+	options := r.options.String()
+	buf.Write(binary.AppendUvarint(nil, uint64(len(options))))
+	buf.WriteString(options)
+	text := WavesText(r.waves)
+	buf.Write(binary.AppendUvarint(nil, uint64(len(text))))
+	buf.WriteString(text)
+	buf.Write(binary.AppendUvarint(nil, r.seed))
+	buf.Write(binary.AppendVarint(nil, int64(r.score)))
+	if r.ended {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	buf.Write(binary.AppendUvarint(nil, uint64(len(r.states))))
+	buf.Write(binary.AppendUvarint(nil, uint64(r.Ticks())))
+	for _, states := range r.states {
+		writeStates(&buf, states)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Write the input of one player as runs of identical ticks
+func writeStates(buf *bytes.Buffer, states [] InputState) {
+	for i := 0; i < len(states); {
+		run := 1
+		for i + run < len(states) && states[i + run] == states[i] {
+			run++
+		}
+		buf.Write(binary.AppendUvarint(nil, uint64(run)))
+		buf.Write(AppendInputState(nil, states[i]))
+		i += run
+	}
+}
+
+// Append the input of one tick to buf
+func AppendInputState(buf [] byte, in InputState) [] byte {
+	buf = binary.AppendUvarint(buf, in.Buttons())
+	buf = binary.AppendVarint(buf, int64(in.cursorX))
+	buf = binary.AppendVarint(buf, int64(in.cursorY))
+	buf = binary.AppendVarint(buf, int64(in.turn))
+	buf = binary.AppendVarint(buf, int64(in.push))
+	return binary.AppendUvarint(buf, uint64(in.weapon))
+}
+
+// Read the input of one tick written by AppendInputState
+func ReadInputState(br io.ByteReader) (InputState, error) {
+	var in InputState
+	buttons, err := binary.ReadUvarint(br)
+	if err != nil {
+		return in, err
+	}
+	values := make([] int64, 4)
+	for i := range values {
+		if values[i], err = binary.ReadVarint(br); err != nil {
+			return in, err
+		}
+	}
+	weapon, err := binary.ReadUvarint(br)
+	if err != nil {
+		return in, err
+	}
+	in = InputState{cursorX: int(values[0]), cursorY: int(values[1]), turn: int(values[2]), push: int(values[3]), weapon: int(weapon)}
+	in.SetButtons(buttons)
+	return in, nil
+}
+
+// Number of ticks recorded
+func (r *Replay) Ticks() int {
+	if len(r.states) == 0 {
+		return 0
+	}
+	return len(r.states[0])
+}
+
+// Read a replay written by Write
+func ReadReplay(rd io.Reader) (*Replay, error) {
+	br := bufio.NewReader(rd)
+	header := make([] byte, len(replayMagic) + 1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:len(replayMagic)], replayMagic) {
+		return nil, errors.New("not a replay file")
+	}
+	if header[len(replayMagic)] != replayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", header[len(replayMagic)])
+	}
+	r := &Replay{}
+	length, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if length > 4096 {
+		return nil, errors.New("corrupt replay file")
+	}
+	options := make([] byte, length)
+	if _, err := io.ReadFull(br, options); err != nil {
+		return nil, err
+	}
+	if r.options, err = ParseOptions(string(options)); err != nil {
+		return nil, err
+	}
+	if length, err = binary.ReadUvarint(br); err != nil {
+		return nil, err
+	}
+	if length > maxWavesLength {
+		return nil, errors.New("corrupt replay file")
+	}
+	waves := make([] byte, length)
+	if _, err := io.ReadFull(br, waves); err != nil {
+		return nil, err
+	}
+	if r.waves, err = ReadWaves(bytes.NewReader(waves)); err != nil {
+		return nil, err
+	}
+	if r.seed, err = binary.ReadUvarint(br); err != nil {
+		return nil, err
+	}
+	score, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, err
+	}
+	r.score = int(score)
+	ended, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if ended > 1 {
+		return nil, errors.New("corrupt replay file")
+	}
+	r.ended = ended == 1
+	players, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if players == 0 || players > MaxPlayers {
+		return nil, errors.New("corrupt replay file")
+	}
+	ticks, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < players; i++ {
+		states, err := readStates(br, ticks)
+		if err != nil {
+			return nil, err
+		}
+		r.states = append(r.states, states)
+	}
+	return r, nil
+}
+
+// Read the input of one player for the number of ticks given
+func readStates(br *bufio.Reader, ticks uint64) ([] InputState, error) {
+	var states [] InputState
+	for uint64(len(states)) < ticks {
+		run, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		in, err := ReadInputState(br)
+		if err != nil {
+			return nil, err
+		}
+		if run == 0 || uint64(len(states)) + run > ticks {
+			return nil, errors.New("corrupt replay file")
+		}
+		for ; run > 0; run-- {
+			states = append(states, in)
+		}
+	}
+	return states, nil
+}
+
+// Save the replay to the file name
+func (r *Replay) Save(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load a replay from the file name
+func LoadReplay(name string) (*Replay, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadReplay(f)
+}
+
+// Input sources that play back the recorded ticks for each player
+func (r *Replay) Inputs() [] InputSource {
+	var inputs [] InputSource
+	for _, states := range r.states {
+		inputs = append(inputs, &ScriptedInput{states: states})
+	}
+	return inputs
+}
+
+// A game that plays back the replay with the waves it was recorded with
+// rather than those in the waves file named in its options
+func (r *Replay) NewGame() *Game {
+	options := r.options
+	options.waves = ""
+	g := NewGame(r.Inputs(), options, r.seed)
+	g.world.options = r.options
+	g.waves = r.waves
+	return g
+}
+
+// Check a game played back from the replay ended, or was still in play, as recorded
+func (r *Replay) Verify(ticks, score int, ended bool) error {
+	if ended != r.ended {
+		if r.ended {
+			return fmt.Errorf("replay was still in play after %d ticks but the recording ended", ticks)
+		}
+		return fmt.Errorf("replay ended after %d ticks but the recording was stopped before the end", ticks)
+	}
+	if ticks != r.Ticks() {
+		return fmt.Errorf("replay ended after %d ticks but %d were recorded", ticks, r.Ticks())
+	}
+	if score != r.score {
+		return fmt.Errorf("replay scored %d but %d was recorded", score, r.score)
+	}
+	return nil
+}
+
+// Records the input read from the source of each player
+// between the start and end of each game
+type Recorder struct {
+	sources		[] InputSource
+	fileName	string
+	replay		*Replay
+}
+
+func NewRecorder(fileName string, sources ...InputSource) *Recorder {
+	return &Recorder{
+		sources:	sources,
+		fileName:	fileName,
+	}
+}
+
+// Input source for one player that records what it reads
+type RecordedInput struct {
+	recorder	*Recorder
+	player		int
+}
+
+func (ri *RecordedInput) Read() InputState {
+	rec := ri.recorder
+	in := rec.sources[ri.player].Read()
+	if rec.replay != nil {
+		rec.replay.states[ri.player] = append(rec.replay.states[ri.player], in)
+	}
+	return in
+}
+
+// Input sources to play with, one for each player
+func (rec *Recorder) Inputs() [] InputSource {
+	var inputs [] InputSource
+	for i := range rec.sources {
+		inputs = append(inputs, &RecordedInput{recorder: rec, player: i})
+	}
+	return inputs
+}
+
+// Start recording a new game played with the options, waves and seed given
+func (rec *Recorder) Begin(options Options, waves [] WaveSpec, seed uint64) {
+	rec.replay = &Replay{options: options, waves: waves, seed: seed, states: make([] [] InputState, len(rec.sources))}
+}
+
+// Stop recording and save the game with its final score, ended is false
+// if the game was quit or stopped before it ended
+func (rec *Recorder) Finish(score int, ended bool) {
+	if rec.replay == nil {
+		return
+	}
+	rec.replay.score = score
+	rec.replay.ended = ended
+	if err := rec.replay.Save(rec.fileName); err != nil {
+		fmt.Println(err)
+		fmt.Println("Unable to write replay file.")
+	}
+	rec.replay = nil
+}
+
+// Play back the replay in the -replay flag, headless if -headless is set,
+// and report whether it matches the recording
+func RunReplayFromFlags() {
+	r, err := LoadReplay(*replayFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *headlessTicks > 0 {
+		result := RunHeadless(r.NewGame(), r.Ticks())
+		fmt.Println(result)
+		if err := r.Verify(result.ticks, result.score, result.gameOver); err != nil {
+			fmt.Println("Replay does not match:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Replay matches the recording")
+		return
+	}
+	g := r.NewGame()
+	g.replay = r
+	g.menuInput = NewMergedInput(NewKeyboardInput(g.bindings), NewGamepadInput(&EbitenGamepad{}))
+	if *spectateAddr != "" {
+		g.Spectate(*spectateAddr)
+	}
+	g.Start()
+	RunGame(g)
+}
@@ -0,0 +1,237 @@
+// Generated rocks
+// for Asteroids written in Go using Ebitengine
+// With the procedural option every meteor is a jagged polygon made from the
+// world's random source so each rock in a game is different but the same
+// seed always makes the same rocks. When a rock is hit it is cut in two
+// along a line through its middle so the fragments are pieces of it.
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/paul63/vector2"
+)
+
+const (
+	RockMinCorners = 9
+	RockMaxCorners = 14
+	RockRoughness = 0.3		// Corners are up to this fraction closer to the centre
+)
+
+var (
+	rockFill = color.RGBA{120, 110, 100, 255}
+	rockEdge = color.RGBA{190, 180, 165, 255}
+	// Source of the colour for filling triangles, made when first drawn
+	whitePixel *ebiten.Image
+)
+
+// Corners of a jagged rock about radius across around 0, 0 in clockwise order
+func NewRockOutline(rng *rand.Rand, radius float64) [] vector2.Vector {
+	corners := RockMinCorners + rng.IntN(RockMaxCorners - RockMinCorners + 1)
+	outline := make([] vector2.Vector, corners)
+	for i := range outline {
+		angle := (float64(i) + rng.Float64() * 0.6) / float64(corners) * 2 * math.Pi
+		r := radius * (1 - rng.Float64() * RockRoughness)
+		outline[i] = vector2.Vector{X: math.Sin(angle) * r, Y: -math.Cos(angle) * r}
+	}
+	return outline
+}
+
+// Create a sprite for the rock with the outline given, its shape is the
+// outline cut into triangles so it matches the rock exactly
+func NewRockSprite(outline [] vector2.Vector) *SpriteImage {
+	parts := Triangulate(outline)
+	if parts == nil {
+		parts = [] [] vector2.Vector {convexHull(slices.Clone(outline))}
+	}
+	shape := NewPolygonShape(parts...)
+	// Even size so the centre of the image is exactly 0, 0
+	size := 2 * int(math.Ceil(shape.radius)) + 4
+	return &SpriteImage{
+		width:		size,
+		height:		size,
+		shape:		shape,
+		outline:	outline,
+	}
+}
+
+// Draw the rock outline into a new image
+func RenderRock(si *SpriteImage) *ebiten.Image {
+	if whitePixel == nil {
+		pixel := ebiten.NewImage(3, 3)
+		pixel.Fill(color.White)
+		whitePixel = pixel.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	}
+	img := ebiten.NewImage(si.width, si.height)
+	half := float32(si.width / 2)
+	var path vector.Path
+	for i, p := range si.outline {
+		if i == 0 {
+			path.MoveTo(half + float32(p.X), half + float32(p.Y))
+		} else {
+			path.LineTo(half + float32(p.X), half + float32(p.Y))
+		}
+	}
+	path.Close()
+	draw := func(vs [] ebiten.Vertex, is [] uint16, c color.RGBA) {
+		for i := range vs {
+			vs[i].SrcX, vs[i].SrcY = 1, 1
+			vs[i].ColorR = float32(c.R) / 255
+			vs[i].ColorG = float32(c.G) / 255
+			vs[i].ColorB = float32(c.B) / 255
+			vs[i].ColorA = float32(c.A) / 255
+		}
+		op := &ebiten.DrawTrianglesOptions{AntiAlias: true}
+		img.DrawTriangles(vs, is, whitePixel, op)
+	}
+	vs, is := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	draw(vs, is, rockFill)
+	vs, is = path.AppendVerticesAndIndicesForStroke(nil, nil, &vector.StrokeOptions{Width: 2, LineJoin: vector.LineJoinRound})
+	draw(vs, is, rockEdge)
+	return img
+}
+
+// Cut a simple polygon into triangles by repeatedly removing ears
+// Returns nil if the polygon cannot be cut, which only happens if its edges cross
+func Triangulate(outline [] vector2.Vector) [] [] vector2.Vector {
+	corners := slices.Clone(outline)
+	// Ears turn the same way as the whole polygon
+	turn := 0.0
+	for i := range corners {
+		turn += cross(vector2.Vector{}, corners[i], corners[(i + 1) % len(corners)])
+	}
+	var triangles [] [] vector2.Vector
+	for len(corners) > 3 {
+		found := false
+		for i := range corners {
+			prev := corners[(i + len(corners) - 1) % len(corners)]
+			next := corners[(i + 1) % len(corners)]
+			c := cross(prev, corners[i], next)
+			if c * turn < 0 {
+				continue
+			}
+			if c != 0 && !anyInside(corners, prev, corners[i], next) {
+				triangles = append(triangles, [] vector2.Vector {prev, corners[i], next})
+			} else if c != 0 {
+				continue
+			}
+			// Remove the ear, or the corner if it is on a straight line
+			corners = append(corners[:i], corners[i+1:]...)
+			found = true
+			break
+		}
+		if !found {
+			return nil
+		}
+	}
+	if cross(corners[0], corners[1], corners[2]) != 0 {
+		triangles = append(triangles, corners)
+	}
+	return triangles
+}
+
+// Check if any corner other than a, b and c is inside the triangle they make
+func anyInside(corners [] vector2.Vector, a, b, c vector2.Vector) bool {
+	for _, p := range corners {
+		if p == a || p == b || p == c {
+			continue
+		}
+		ab, bc, ca := cross(a, b, p), cross(b, c, p), cross(c, a, p)
+		if (ab >= 0 && bc >= 0 && ca >= 0) || (ab <= 0 && bc <= 0 && ca <= 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// Centre of the area of a polygon
+func Centroid(outline [] vector2.Vector) vector2.Vector {
+	var centre vector2.Vector
+	area := 0.0
+	for i, p := range outline {
+		q := outline[(i + 1) % len(outline)]
+		a := p.X * q.Y - q.X * p.Y
+		area += a
+		centre.X += (p.X + q.X) * a
+		centre.Y += (p.Y + q.Y) * a
+	}
+	if area == 0 {
+		return outline[0]
+	}
+	centre.X /= 3 * area
+	centre.Y /= 3 * area
+	return centre
+}
+
+// Keep the part of the polygon on the side of the line through point
+// that normal points to
+func clipOutline(outline [] vector2.Vector, point, normal vector2.Vector) [] vector2.Vector {
+	side := func(p vector2.Vector) float64 {
+		return (p.X - point.X) * normal.X + (p.Y - point.Y) * normal.Y
+	}
+	var clipped [] vector2.Vector
+	for i, p := range outline {
+		q := outline[(i + 1) % len(outline)]
+		sp, sq := side(p), side(q)
+		if sp >= 0 {
+			clipped = append(clipped, p)
+		}
+		if (sp >= 0) != (sq >= 0) {
+			t := sp / (sp - sq)
+			clipped = append(clipped, vector2.Vector{X: p.X + (q.X - p.X) * t, Y: p.Y + (q.Y - p.Y) * t})
+		}
+	}
+	return clipped
+}
+
+// Cut the polygon in two along a line at angle through its centre
+// Each piece is returned around its own centre with where that centre was
+func SplitOutline(outline [] vector2.Vector, angle float64) (pieces [2] [] vector2.Vector, centres [2] vector2.Vector) {
+	centre := Centroid(outline)
+	normal := vector2.Vector{X: math.Cos(angle), Y: math.Sin(angle)}
+	for i := range pieces {
+		piece := clipOutline(outline, centre, normal)
+		if len(piece) >= 3 {
+			centres[i] = Centroid(piece)
+			for j := range piece {
+				piece[j].X -= centres[i].X
+				piece[j].Y -= centres[i].Y
+			}
+			pieces[i] = piece
+		}
+		normal.X, normal.Y = -normal.X, -normal.Y
+	}
+	return pieces, centres
+}
+
+// Radius of a generated rock of size (0 to 3), the same as the images
+func RockRadius(size int) float64 {
+	return float64(meteorSprites[size].width) / 2
+}
+
+// Break a generated rock in two, it becomes one piece and a new fragment
+// of size the other, falling back to new rocks if a piece is too small
+func (m *Meteor) Split() {
+	w := m.world
+	pieces, centres := SplitOutline(m.sprite.outline, w.rng.Float64() * math.Pi)
+	fragment := NewFragment(w, m.size, m.position.X, m.position.Y)
+	fragment.angle = m.angle
+	for i, met := range [] *Meteor {m, fragment} {
+		if pieces[i] == nil {
+			met.SetSprite(NewRockSprite(NewRockOutline(w.rng, RockRadius(met.size))))
+			continue
+		}
+		// Move the piece to where it was in the rock allowing for its rotation
+		sin, cos := math.Sincos(m.angle)
+		met.position.X += centres[i].X * cos - centres[i].Y * sin
+		met.position.Y += centres[i].X * sin + centres[i].Y * cos
+		met.SetSprite(NewRockSprite(pieces[i]))
+	}
+}
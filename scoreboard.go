@@ -14,6 +14,7 @@ import (
 	"strconv"	
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 // Embeds file specified into scoreRegular
@@ -42,10 +43,13 @@ func LoadFont(fontStream []byte) *text.GoTextFaceSource{
 }
 
 type ScoreBoard struct {
-	score int
 	highScore int
-	lives	int
+	wave	int		// Wave in play
+	round	int		// Round in play in versus
+	versus	bool	// Show kills instead of lives and the round instead of the high score
+	killsToWin	int
 	highScoreSaved	bool
+	seed	uint64		// Random seed of the current game
 }
 
 func (sb *ScoreBoard) LoadHighScore() int {
@@ -61,9 +65,7 @@ func (sb *ScoreBoard) LoadHighScore() int {
 
 func NewScoreBoard() *ScoreBoard{
 	sb := ScoreBoard {
-		score: 0,
 		highScore: 0,
-		lives: 3,
 		highScoreSaved: false,
 	}
 	sb.LoadHighScore()
@@ -73,10 +75,10 @@ func NewScoreBoard() *ScoreBoard{
 	return &sb
 }
 
-func (sb *ScoreBoard) SaveHighScore(){
+func (sb *ScoreBoard) SaveHighScore(total int){
 	if !sb.highScoreSaved {
 		// Save new high score
-		score := []byte(fmt.Sprint(sb.score))
+		score := []byte(fmt.Sprint(total))
 		// Note 0644 is there for file create
 		// 	  The file's owner can read and write (6)
 		//    Users in the same group as the file's owner can read (first 4)
@@ -91,36 +93,119 @@ func (sb *ScoreBoard) SaveHighScore(){
 }
 
 // Draws sprite on the screen center at x, y and rotated by rotate
-func DrawLife(screen  *ebiten.Image, sprite *ebiten.Image, x, y float64) {
+func DrawLife(screen  *ebiten.Image, sprite *SpriteImage, x, y float64) {
 	op := &ebiten.DrawImageOptions{}
-	width := sprite.Bounds().Dx()
-	height := sprite.Bounds().Dy()
+	width := sprite.width
+	height := sprite.height
 	halfW := float64(width / 2)
 	halfH := float64(height / 2)
 	// move image so center aligns with 0, 0
 	op.GeoM.Translate(-halfW, -halfH)
 	// move it to required position X & Y will be center of sprite as relative to 0,0
 	op.GeoM.Translate(x , y)
-	screen.DrawImage(sprite, op)
+	screen.DrawImage(sprite.Image(), op)
 
 }
 
-func (sb *ScoreBoard) DrawScore(screen *ebiten.Image){
+// Score of every player added together, used for the high score
+func TotalScore(players [] *Player) int {
+	total := 0
+	for _, p := range players {
+		total += p.score
+	}
+	return total
+}
+
+// Draw the score, wave and lives with the time left of any effects the players have
+func (sb *ScoreBoard) DrawScore(screen *ebiten.Image, players [] *Player){
+	if len(players) > 1 {
+		sb.DrawCoopScore(screen, players)
+		return
+	}
+	player := players[0]
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(20, 20)
-	text.Draw(screen, fmt.Sprintf("Score: %06d", sb.score), &text.GoTextFace{
+	text.Draw(screen, fmt.Sprintf("Score: %06d", player.score), &text.GoTextFace{
 		Source: scoreFace,
 		Size:   20,
 	}, op)	
-	op = &text.DrawOptions{}
+	sb.DrawHighScoreAndWave(screen)
+	for i := 0; i < player.lives; i++ {
+		DrawLife(screen, playerSprite, float64(ScreenWidth - 35 - i * 40), 30)
+	}	
+	sb.DrawLeft(screen, player.WeaponText(), 300, 50, 16, white)
+	sb.DrawEffectTimes(screen, player, 20, 55)
+	sb.DrawDeflectorBar(screen, player, ScreenWidth - 20 - DeflectorBarWidth, 55)
+}
+
+// Draw a panel for each player with its score, lives or kills, weapon,
+// deflector and effects, the first on the left and the second on the right
+func (sb *ScoreBoard) DrawCoopScore(screen *ebiten.Image, players [] *Player){
+	sb.DrawHighScoreAndWave(screen)
+	for i, player := range players {
+		x := 20 + i * (ScreenWidth - 240)
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(x), 20)
+		text.Draw(screen, fmt.Sprintf("P%d %06d", i + 1, player.score), &text.GoTextFace{
+			Source: scoreFace,
+			Size:   20,
+		}, op)
+		if sb.versus {
+			sb.DrawLeft(screen, fmt.Sprintf("Kills: %d of %d", player.kills, sb.killsToWin), x, 55, 20, yellow)
+		} else {
+			for l := 0; l < player.lives; l++ {
+				DrawLife(screen, player.ShipSprite(false), float64(x + 15 + l * 40), 70)
+			}
+		}
+		sb.DrawLeft(screen, player.WeaponText(), x, 95, 16, white)
+		sb.DrawDeflectorBar(screen, player, float32(x), 122)
+		sb.DrawEffectTimes(screen, player, x, 140)
+	}
+}
+
+// Draw the high score, or round in versus, and wave number across the top of the screen
+func (sb *ScoreBoard) DrawHighScoreAndWave(screen *ebiten.Image){
+	op := &text.DrawOptions{}
 	op.GeoM.Translate(300, 20)
-	text.Draw(screen, fmt.Sprintf("High Score: %06d",sb.highScore), &text.GoTextFace{
+	top := fmt.Sprintf("High Score: %06d", sb.highScore)
+	if sb.versus {
+		top = fmt.Sprintf("Round: %d", sb.round)
+	}
+	text.Draw(screen, top, &text.GoTextFace{
 		Source: scoreFace,
 		Size:   20,
 	}, op)
-	for i := 0; i < sb.lives; i++ {
-		DrawLife(screen, playerSprite, float64(ScreenWidth - 35 - i * 40), 30)
-	}	
+	op = &text.DrawOptions{}
+	op.GeoM.Translate(640, 20)
+	text.Draw(screen, fmt.Sprintf("Wave: %d", sb.wave), &text.GoTextFace{
+		Source: scoreFace,
+		Size:   20,
+	}, op)
+}
+
+// Name of the player's weapon with its ammo
+func (p *Player) WeaponText() string {
+	weapon := p.Weapon()
+	ammo := ""
+	if weapon.ammo != Unlimited {
+		ammo = fmt.Sprintf(" (%d)", p.ammo[p.weapon])
+	}
+	return fmt.Sprintf("Weapon %d: %s%s", p.weapon + 1, weapon.name, ammo)
+}
+
+// List the time left of each effect the player has starting at x, y
+func (sb *ScoreBoard) DrawEffectTimes(screen *ebiten.Image, player *Player, x, y int){
+	for e := Effect(0); e < NumEffects; e++ {
+		if player.HasEffect(e) {
+			sb.DrawLeft(screen, fmt.Sprintf("%s %.1f", e.Name(), player.EffectRemaining(e)), x, y, 16, effectColors[e])
+			y += 22
+		}
+	}
+}
+
+// Show the number of the wave starting
+func (sb *ScoreBoard) DrawWaveBanner(screen *ebiten.Image) {
+	sb.DrawCenter(screen, fmt.Sprintf("Wave %d", sb.wave), ScreenWidth/2, 320, 60, yellow)
 }
 
 func (sb *ScoreBoard) DrawCenter(screen *ebiten.Image, s string, x, y, size int, color color.Color) {
@@ -145,42 +230,124 @@ func (sb *ScoreBoard) DrawLeft(screen *ebiten.Image, s string, x, y, size int, c
 	}, op)	
 }
 
-func (sb *ScoreBoard) DrawInstructions(screen *ebiten.Image){
+// Show the instructions, with the gamepad controls if one is connected
+func (sb *ScoreBoard) DrawInstructions(screen *ebiten.Image, b *Bindings, gamepad bool){
 
 	instructions := `Destroy the asteroids before they hit you.
-A new asteroid appears every 3 seconds but the frequency increases
-as the game progresses.
+Clear every asteroid to move on to the next wave, each wave has more
+or faster asteroids than the last.
 You have 3 lives.
 
-Easy mode:
-    Position the mouse pointer in the direction of the asteroid
-    and press the left mouse button to fire.
-    Right button to move.
-    Middle button to hyperjump.
-
-Hard mode. Press:
-    Left and right arrow to rotate ship.
-    Down arrow to reverse direction of ship.
-    Up arrow to move.
-    H to hyperjump.
-    Space to fire.
-
-You can have multiple missiles flying at one time.
+`
+	start := b.Names(ActionStart)
+	if gamepad {
+		instructions += "Gamepad controls:\n"
+		for a := ActionRotateLeft; a < ActionStart; a++ {
+			if name, ok := gamepadButtonNames[a]; ok {
+				instructions += fmt.Sprintf("    %s: %s\n", a.Title(), name)
+			}
+		}
+		instructions += "    Aim with the mouse pointer and keyboard controls still work (press C to change)\n"
+		start = gamepadButtonNames[ActionStart] + " or " + start
+	} else {
+		instructions += "Controls (press C to change):\n"
+		for a := ActionRotateLeft; a < ActionStart; a++ {
+			instructions += fmt.Sprintf("    %s: %s\n", a.Title(), b.Names(a))
+		}
+	}
+	instructions += `
+Keys 1 to 5 choose a weapon, you can have multiple missiles flying at once.
 Score for hitting an asteroid:
     Large = 25 Medium = 50 Small = 75 Tiny = 100 points`
 
 	sb.DrawCenter(screen, "Asteroids", ScreenWidth/2, 20, 40, yellow)	
 	sb.DrawLeft(screen, instructions, 200, 100, 20, white)
-	sb.DrawCenter(screen, "Press space bar to play", ScreenWidth/2, 735, 30, aqua)
+	sb.DrawCenter(screen, fmt.Sprintf("Press %s to play", start), ScreenWidth/2, 735, 30, aqua)
 }
 
-func (sb *ScoreBoard) DrawGameOver(screen *ebiten.Image){
+// Show the final score, in co-op the team score is the high score and
+// each player's own score is shown below it
+func (sb *ScoreBoard) DrawGameOver(screen *ebiten.Image, b *Bindings, players [] *Player){
+	score := TotalScore(players)
 	sb.DrawCenter(screen, "Asteroids", ScreenWidth/2, 20, 40, yellow)	
 	sb.DrawCenter(screen, "Game Over", ScreenWidth/2, 200, 40, white)	
-	sb.DrawCenter(screen, fmt.Sprintf("Your Score: %06d", sb.score), ScreenWidth/2, 400, 40, white)	
-	if sb.score > sb.highScore {
+	if len(players) > 1 {
+		sb.DrawCenter(screen, fmt.Sprintf("Team Score: %06d", score), ScreenWidth/2, 360, 40, white)
+		sb.DrawCenter(screen, fmt.Sprintf("Player 1: %06d    Player 2: %06d", players[0].score, players[1].score),
+			ScreenWidth/2, 420, 30, white)
+	} else {
+		sb.DrawCenter(screen, fmt.Sprintf("Your Score: %06d", score), ScreenWidth/2, 400, 40, white)	
+	}
+	if score > sb.highScore {
 		sb.DrawCenter(screen, "Congratulations a new high score", ScreenWidth/2, 500, 60, green)
-		sb.SaveHighScore()	
+		sb.SaveHighScore(score)	
+	}
+	sb.DrawCenter(screen, fmt.Sprintf("Seed: %d", sb.seed), ScreenWidth/2, 640, 20, white)
+	sb.DrawCenter(screen, fmt.Sprintf("Press %s to play again", b.Names(ActionStart)), ScreenWidth/2, 700, 30, aqua)
+}
+
+// Show how co-op or versus is played with the controls of each player side by side
+func (sb *ScoreBoard) DrawTwoPlayerInstructions(screen *ebiten.Image, b *Bindings, players [MaxPlayers] *Bindings, versus bool){
+	title := "Asteroids - Co-op"
+	instructions := `Two players share the asteroid field, each with their own score and 3 lives.
+A player who is hit comes back at their start once nothing is close.
+If both players are down together the wave starts again.
+The game ends when both players are out.`
+	if versus {
+		title = "Asteroids - Versus"
+		instructions = fmt.Sprintf(`Shoot down the other ship, the asteroids and saucers are a danger to both.
+A round ends when a ship is destroyed, shooting down the other ship scores a kill
+but flying into an asteroid or being hit by a saucer scores nothing.
+The first player to %d kills wins.`, sb.killsToWin)
+	}
+
+	sb.DrawCenter(screen, title, ScreenWidth/2, 20, 40, yellow)
+	sb.DrawLeft(screen, instructions, 100, 100, 20, white)
+	for i, pb := range players {
+		x := 100 + i * 420
+		sb.DrawLeft(screen, fmt.Sprintf("Player %d:", i + 1), x, 260, 20, aqua)
+		y := 300
+		for a := ActionRotateLeft; a < ActionPause; a++ {
+			if len(pb[a]) > 0 {
+				sb.DrawLeft(screen, fmt.Sprintf("%s: %s", a.Title(), pb.Names(a)), x + 20, y, 18, white)
+				y += 32
+			}
+		}
+	}
+	sb.DrawLeft(screen, "Keys 1 to 5 and the mouse wheel choose a weapon for player 1.", 100, 640, 20, white)
+	sb.DrawCenter(screen, fmt.Sprintf("Press %s to play", b.Names(ActionStart)), ScreenWidth/2, 735, 30, aqua)
+}
+
+func (sb *ScoreBoard) DrawControls(screen *ebiten.Image, cs *ControlsScreen){
+	sb.DrawCenter(screen, "Controls", ScreenWidth/2, 20, 40, yellow)
+	for a := Action(0); a < NumActions; a++ {
+		c := white
+		names := cs.bindings.Names(a)
+		if a == cs.selected {
+			c = aqua
+			if cs.waiting {
+				names = "press a key or mouse button"
+			}
+		}
+		y := 120 + int(a) * 40
+		sb.DrawLeft(screen, a.Title(), 200, y, 20, c)
+		sb.DrawLeft(screen, names, 520, y, 20, c)
+	}
+	sb.DrawCenter(screen, "Up and down arrows to select, Enter to change", ScreenWidth/2, 640, 20, white)
+	sb.DrawCenter(screen, "Backspace to restore the default, Escape to save and return", ScreenWidth/2, 680, 20, white)
+}
+
+// Draw the pause menu over the game with the selected item highlighted
+func (sb *ScoreBoard) DrawPauseMenu(screen *ebiten.Image, pm *PauseMenu, b *Bindings){
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 160}, false)
+	sb.DrawCenter(screen, "Paused", ScreenWidth/2, 200, 40, yellow)
+	for i, item := range menuItems {
+		c := white
+		if i == pm.selected {
+			c = aqua
+		}
+		sb.DrawCenter(screen, item, ScreenWidth/2, 320 + i * 60, 30, c)
 	}
-	sb.DrawCenter(screen, "Press space bar to play again", ScreenWidth/2, 700, 30, aqua)
+	sb.DrawCenter(screen, fmt.Sprintf("%s / %s to select, %s to choose", b.Names(ActionThrust),
+		b.Names(ActionReverse), b.Names(ActionFire)), ScreenWidth/2, 640, 20, white)
 }
@@ -0,0 +1,345 @@
+// Collision shapes
+// for Asteroids written in Go using Ebitengine
+// Each sprite image has a convex polygon fitted around its visible pixels.
+// The polygon is rotated with the sprite and checked with the separating
+// axis test so sprites only collide where they can be seen to touch.
+// Shapes that are not convex, such as generated rocks, are made of several
+// convex parts.
+
+package main
+
+import (
+	"cmp"
+	"flag"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/paul63/vector2"
+)
+
+const (
+	ShapeAlpha = 0x4000		// Pixels more opaque than this are part of the shape (of 0xffff)
+	MaxShapePoints = 16		// Polygons are simplified to at most this many corners
+)
+
+var showShapesFlag = flag.Bool("shapes", false, "draw collision shapes over the sprites (toggle with F3)")
+
+type Shape struct {
+	parts	[] [] vector2.Vector	// Convex polygons with corners relative to the centre of the image
+	radius	float64					// Distance of the furthest corner from the centre
+}
+
+// Fit a convex polygon around the pixels in img that are not transparent
+// with the centre of the image at 0, 0 as it is when drawn
+func NewShape(img image.Image) *Shape {
+	bounds := img.Bounds()
+	halfW := float64(bounds.Dx() / 2)
+	halfH := float64(bounds.Dy() / 2)
+	// Only the outer corners of the first and last visible pixel on each row
+	// can be on the hull
+	var corners [] vector2.Vector
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		first, last := -1, -1
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > ShapeAlpha {
+				if first < 0 {
+					first = x
+				}
+				last = x
+			}
+		}
+		if first >= 0 {
+			top := float64(y - bounds.Min.Y) - halfH
+			left := float64(first - bounds.Min.X) - halfW
+			right := float64(last + 1 - bounds.Min.X) - halfW
+			corners = append(corners,
+				vector2.Vector{X: left, Y: top}, vector2.Vector{X: left, Y: top + 1},
+				vector2.Vector{X: right, Y: top}, vector2.Vector{X: right, Y: top + 1})
+		}
+	}
+	return NewPolygonShape(simplifyHull(convexHull(corners), MaxShapePoints))
+}
+
+// Shape made of the convex polygons given
+func NewPolygonShape(parts ...[] vector2.Vector) *Shape {
+	radius := 0.0
+	for _, part := range parts {
+		for _, p := range part {
+			radius = math.Max(radius, p.Length())
+		}
+	}
+	return &Shape{parts: parts, radius: radius}
+}
+
+// Smallest convex polygon containing all the points (monotone chain)
+func convexHull(points [] vector2.Vector) [] vector2.Vector {
+	if len(points) < 3 {
+		return points
+	}
+	slices.SortFunc(points, func(a, b vector2.Vector) int {
+		if a.X != b.X {
+			return cmp.Compare(a.X, b.X)
+		}
+		return cmp.Compare(a.Y, b.Y)
+	})
+	hull := make([] vector2.Vector, 0, 2 * len(points))
+	// Lower then upper half, dropping any corner that does not turn the same way
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range points {
+			for len(hull) >= start + 2 && cross(hull[len(hull) - 2], hull[len(hull) - 1], p) <= 0 {
+				hull = hull[:len(hull) - 1]
+			}
+			hull = append(hull, p)
+		}
+		// The last point is the first of the other half
+		hull = hull[:len(hull) - 1]
+		slices.Reverse(points)
+	}
+	return hull
+}
+
+// Remove the corners that change the area least until there are at most
+// max left, the polygon stays convex but shrinks very slightly
+func simplifyHull(hull [] vector2.Vector, max int) [] vector2.Vector {
+	for len(hull) > max {
+		smallest, area := 0, math.Inf(1)
+		for i := range hull {
+			prev := hull[(i + len(hull) - 1) % len(hull)]
+			next := hull[(i + 1) % len(hull)]
+			if a := math.Abs(cross(prev, hull[i], next)); a < area {
+				smallest, area = i, a
+			}
+		}
+		hull = append(hull[:smallest], hull[smallest+1:]...)
+	}
+	return hull
+}
+
+// Twice the signed area of the triangle a, b, c, positive if it turns clockwise on screen
+func cross(a, b, c vector2.Vector) float64 {
+	return (b.X - a.X) * (c.Y - a.Y) - (b.Y - a.Y) * (c.X - a.X)
+}
+
+// Return the parts of the shape rotated by angle, as the sprite is drawn,
+// and moved to pos reusing the memory of placed
+func (s *Shape) Place(pos vector2.Vector, angle float64, placed [] [] vector2.Vector) [] [] vector2.Vector {
+	sin, cos := math.Sincos(angle)
+	placed = placed[:0]
+	for i, part := range s.parts {
+		var corners [] vector2.Vector
+		if i < cap(placed) {
+			corners = placed[:i + 1][i][:0]
+		}
+		for _, p := range part {
+			corners = append(corners, vector2.Vector{
+				X: pos.X + p.X * cos - p.Y * sin,
+				Y: pos.Y + p.X * sin + p.Y * cos,
+			})
+		}
+		placed = append(placed, corners)
+	}
+	return placed
+}
+
+// Check if any part of one placed shape overlaps any part of the other
+func ShapesOverlap(a, b [] [] vector2.Vector) bool {
+	for _, partA := range a {
+		for _, partB := range b {
+			if PolygonsOverlap(partA, partB) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check if two convex polygons overlap, they are apart if there is
+// a line along one of their edges that separates them
+func PolygonsOverlap(a, b [] vector2.Vector) bool {
+	return !separated(a, b) && !separated(b, a)
+}
+
+// Check if any edge of a is a separating axis between a and b
+func separated(a, b [] vector2.Vector) bool {
+	for i := range a {
+		p, q := a[i], a[(i + 1) % len(a)]
+		// Normal to the edge
+		axis := vector2.Vector{X: q.Y - p.Y, Y: p.X - q.X}
+		minA, maxA := project(a, axis)
+		minB, maxB := project(b, axis)
+		if maxA <= minB || maxB <= minA {
+			return true
+		}
+	}
+	return false
+}
+
+// Range of the points along axis
+func project(points [] vector2.Vector, axis vector2.Vector) (low, high float64) {
+	low, high = math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		d := p.Dot(axis)
+		low = math.Min(low, d)
+		high = math.Max(high, d)
+	}
+	return
+}
+
+// Square around every angle the sprite can be rotated to, used for the broad phase
+func (gs *GameSprite) Bounds() ScreenPos {
+	size := int(math.Ceil(gs.sprite.Shape().radius * 2))
+	return NewScreenPos(gs.position.X, gs.position.Y, size, size)
+}
+
+// Check if the shapes of two sprites overlap allowing for wrapping if it is on
+func (w *World) Collides(a, b *GameSprite) bool {
+	if a.done || b.done {
+		return false
+	}
+	// Place a at the origin and b where it is relative to a
+	offset := vector2.Vector{X: b.position.X - a.position.X, Y: b.position.Y - a.position.Y}
+	if w.options.wrap {
+		offset.X = WrapDelta(offset.X, ScreenWidth)
+		offset.Y = WrapDelta(offset.Y, ScreenHeight)
+	}
+	shapeA, shapeB := a.sprite.Shape(), b.sprite.Shape()
+	// Too far apart to touch at any angle
+	if reach := shapeA.radius + shapeB.radius; offset.X * offset.X + offset.Y * offset.Y >= reach * reach {
+		return false
+	}
+	w.placedA = shapeA.Place(vector2.Vector{}, a.angle, w.placedA)
+	w.placedB = shapeB.Place(offset, b.angle, w.placedB)
+	return ShapesOverlap(w.placedA, w.placedB)
+}
+
+// Check if a circle at center overlaps the shape of b allowing for wrapping if it is on
+func (w *World) CollidesCircle(center vector2.Vector, radius float64, b *GameSprite) bool {
+	if b.done {
+		return false
+	}
+	offset := vector2.Vector{X: b.position.X - center.X, Y: b.position.Y - center.Y}
+	if w.options.wrap {
+		offset.X = WrapDelta(offset.X, ScreenWidth)
+		offset.Y = WrapDelta(offset.Y, ScreenHeight)
+	}
+	shape := b.sprite.Shape()
+	if reach := radius + shape.radius; offset.X * offset.X + offset.Y * offset.Y >= reach * reach {
+		return false
+	}
+	w.placedB = shape.Place(offset, b.angle, w.placedB)
+	for _, part := range w.placedB {
+		if CircleOverlapsPolygon(vector2.Vector{}, radius, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the line from start to end crosses the shape of b allowing for
+// wrapping if it is on, when wrapping the line must be shorter than half
+// the screen for b to be found along it
+func (w *World) CollidesSegment(start, end vector2.Vector, b *GameSprite) bool {
+	if b.done {
+		return false
+	}
+	offset := vector2.Vector{X: b.position.X - start.X, Y: b.position.Y - start.Y}
+	if w.options.wrap {
+		offset.X = WrapDelta(offset.X, ScreenWidth)
+		offset.Y = WrapDelta(offset.Y, ScreenHeight)
+	}
+	line := vector2.Vector{X: end.X - start.X, Y: end.Y - start.Y}
+	shape := b.sprite.Shape()
+	if distanceToEdge(offset, vector2.Vector{}, line) >= shape.radius {
+		return false
+	}
+	w.placedB = shape.Place(offset, b.angle, w.placedB)
+	// The line is a polygon with no area so the separating axis test works for it too
+	segment := [] vector2.Vector {{}, line}
+	for _, part := range w.placedB {
+		if PolygonsOverlap(segment, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if a circle overlaps a convex polygon, it does if an edge is
+// closer to the centre than radius or the centre is inside the polygon
+func CircleOverlapsPolygon(centre vector2.Vector, radius float64, polygon [] vector2.Vector) bool {
+	left, right := false, false
+	for i := range polygon {
+		p, q := polygon[i], polygon[(i + 1) % len(polygon)]
+		if distanceToEdge(centre, p, q) < radius {
+			return true
+		}
+		if cross(p, q, centre) < 0 {
+			left = true
+		} else {
+			right = true
+		}
+	}
+	// Inside if the centre is on the same side of every edge
+	return !(left && right)
+}
+
+// Distance from c to the closest point on the edge from p to q
+func distanceToEdge(c, p, q vector2.Vector) float64 {
+	edge := vector2.Vector{X: q.X - p.X, Y: q.Y - p.Y}
+	t := 0.0
+	if length := edge.Dot(edge); length > 0 {
+		t = math.Max(0, math.Min(1, ((c.X - p.X) * edge.X + (c.Y - p.Y) * edge.Y) / length))
+	}
+	return math.Hypot(p.X + edge.X * t - c.X, p.Y + edge.Y * t - c.Y)
+}
+
+// Outline the collision shape of the sprite
+func (gs *GameSprite) DrawShape(screen *ebiten.Image, placed [] [] vector2.Vector) [] [] vector2.Vector {
+	if gs.done {
+		return placed
+	}
+	placed = gs.sprite.Shape().Place(gs.position, gs.angle, placed)
+	for _, part := range placed {
+		for i, p := range part {
+			q := part[(i + 1) % len(part)]
+			vector.StrokeLine(screen, float32(p.X), float32(p.Y), float32(q.X), float32(q.Y), 1, shapeColor, true)
+		}
+	}
+	return placed
+}
+
+var shapeColor = color.RGBA{0, 255, 0, 255}
+
+// Outline the collision shapes of every meteor, missile, saucer and pickup
+func (w *World) DrawShapes(screen *ebiten.Image) {
+	for _, m := range w.meteors {
+		w.placedA = m.DrawShape(screen, w.placedA)
+	}
+	for _, m := range w.missiles {
+		// A beam hits along the line drawn for it
+		if m.weapon.behaviour != MissileBeam {
+			w.placedA = m.DrawShape(screen, w.placedA)
+		}
+	}
+	for _, s := range w.saucers {
+		w.placedA = s.DrawShape(screen, w.placedA)
+	}
+	for _, s := range w.saucerShots {
+		w.placedA = s.DrawShape(screen, w.placedA)
+	}
+	for _, p := range w.pickups {
+		w.placedA = p.DrawShape(screen, w.placedA)
+	}
+}
+
+// Turn drawing the collision shapes on and off when F3 is pressed
+func (g *Game) UpdateShowShapes() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.showShapes = !g.showShapes
+	}
+}
@@ -0,0 +1,111 @@
+// Spatial hash used as a broad phase for collisions
+// for Asteroids written in Go using Ebitengine
+// The screen is divided into a uniform grid of cells. Each entity is added
+// to every cell its bounding box overlaps so only entities sharing a cell
+// need to be checked with Collides.
+
+package main
+
+import (
+	"math"
+)
+
+// Largest cell size, a little larger than the largest meteor
+const HashCellSize = 80
+
+type SpatialHash struct {
+	cellWidth	float64
+	cellHeight	float64
+	cols		int
+	rows		int
+	wrap		bool		// Cells wrap around the screen edges
+	cells		[] [] int	// Ids of the entities in each cell
+	seen		[] int		// Query that last found each id to avoid duplicates
+	query		int
+}
+
+// Create a grid covering the screen with cells no larger than cellSize,
+// if wrap is true entities past one edge are added to the cells at the
+// opposite edge
+// The cells fit the screen exactly so the grid wraps where the screen does
+func NewSpatialHash(cellSize float64, wrap bool) *SpatialHash {
+	cols := int(math.Ceil(ScreenWidth / cellSize))
+	rows := int(math.Ceil(ScreenHeight / cellSize))
+	return &SpatialHash{
+		cellWidth:	ScreenWidth / float64(cols),
+		cellHeight:	ScreenHeight / float64(rows),
+		cols:		cols,
+		rows:		rows,
+		wrap:		wrap,
+		cells:		make([] [] int, cols * rows),
+	}
+}
+
+// Remove all entities keeping the memory for the next rebuild
+func (sh *SpatialHash) Clear() {
+	for i := range sh.cells {
+		sh.cells[i] = sh.cells[i][:0]
+	}
+}
+
+// Range of cells the bounding box of pos overlaps, the ends may be outside
+// the grid when wrapping and are reduced to the grid size with cell
+func (sh *SpatialHash) cellRange(pos ScreenPos) (x0, x1, y0, y1 int) {
+	x0 = int(math.Floor((pos.X - float64(pos.Width) / 2) / sh.cellWidth))
+	x1 = int(math.Floor((pos.X + float64(pos.Width) / 2) / sh.cellWidth))
+	y0 = int(math.Floor((pos.Y - float64(pos.Height) / 2) / sh.cellHeight))
+	y1 = int(math.Floor((pos.Y + float64(pos.Height) / 2) / sh.cellHeight))
+	if sh.wrap {
+		// Never visit a cell twice if the box is wider than the screen
+		x1 = min(x1, x0 + sh.cols - 1)
+		y1 = min(y1, y0 + sh.rows - 1)
+	} else {
+		// Anything off the screen goes in the edge cells
+		x0, x1 = clamp(x0, 0, sh.cols - 1), clamp(x1, 0, sh.cols - 1)
+		y0, y1 = clamp(y0, 0, sh.rows - 1), clamp(y1, 0, sh.rows - 1)
+	}
+	return
+}
+
+// Index of the cell at column x and row y wrapping them onto the grid
+func (sh *SpatialHash) cell(x, y int) int {
+	col := (x % sh.cols + sh.cols) % sh.cols
+	row := (y % sh.rows + sh.rows) % sh.rows
+	return row * sh.cols + col
+}
+
+// Add the entity with the id given at pos
+func (sh *SpatialHash) Insert(id int, pos ScreenPos) {
+	for id >= len(sh.seen) {
+		sh.seen = append(sh.seen, 0)
+	}
+	x0, x1, y0, y1 := sh.cellRange(pos)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			c := sh.cell(x, y)
+			sh.cells[c] = append(sh.cells[c], id)
+		}
+	}
+}
+
+// Append the ids of entities that may collide with pos to found
+// Each id is only added once but they are not in any order
+func (sh *SpatialHash) Query(pos ScreenPos, found [] int) [] int {
+	sh.query++
+	x0, x1, y0, y1 := sh.cellRange(pos)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			for _, id := range sh.cells[sh.cell(x, y)] {
+				if sh.seen[id] != sh.query {
+					sh.seen[id] = sh.query
+					found = append(found, id)
+				}
+			}
+		}
+	}
+	return found
+}
+
+func clamp(v, low, high int) int {
+	return max(low, min(v, high))
+}
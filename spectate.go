@@ -0,0 +1,417 @@
+// Spectator server
+// for Asteroids written in Go using Ebitengine
+// Serves a snapshot of the game as JSON over WebSocket every tick so it can be
+// watched on another screen, with a small viewer page that draws it on a canvas.
+// Only what the viewer needs is sent and nothing is read back, so spectators
+// never change the game.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var spectateAddr = flag.String("spectate", "", "serve the game to spectators on this address e.g. :8080")
+
+// WebSocket frame opcodes
+const (
+	OpText = 0x1
+	OpClose = 0x8
+	OpPing = 0x9
+	OpPong = 0xa
+)
+
+// Added to the client's key to make the handshake reply
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Spectators only send control frames so anything bigger is refused
+const maxFrameSize = 4096
+
+var modeNames = map[int] string {
+	Inst:		"title",
+	InPlay:		"play",
+	GameOver:	"gameover",
+	Controls:	"controls",
+	Paused:		"paused",
+	RoundOver:	"roundover",
+	Network:	"network",
+}
+
+type SpectatorServer struct {
+	mu			sync.Mutex
+	spectators	map[*Spectator] bool
+	address		string
+}
+
+// A viewer connected over WebSocket
+type Spectator struct {
+	conn		net.Conn
+	writeMu		sync.Mutex
+	frames		chan [] byte	// Latest snapshot waiting to be sent
+	done		chan struct{}
+	closeOnce	sync.Once
+}
+
+// Listen on address and serve the viewer page at / and the snapshots at /ws
+func StartSpectatorServer(address string) (*SpectatorServer, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	ss := &SpectatorServer{
+		spectators:	map[*Spectator] bool{},
+		address:	listener.Addr().String(),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", ss.ServeViewer)
+	mux.HandleFunc("/ws", ss.ServeStream)
+	go http.Serve(listener, mux)
+	return ss, nil
+}
+
+// Serve spectators on address, printing where they can watch
+func (g *Game) Spectate(address string) {
+	ss, err := StartSpectatorServer(address)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("Unable to start spectator server.")
+		return
+	}
+	fmt.Printf("Spectators can watch at http://%s/\n", ss.address)
+	g.spectators = ss
+}
+
+func (ss *SpectatorServer) ServeViewer(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	page, err := assets.ReadFile("assets/spectate.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
+
+// Upgrade the request to a WebSocket and send it snapshots until it closes
+func (ss *SpectatorServer) ServeStream(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a WebSocket", http.StatusBadRequest)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "cannot upgrade connection", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	sum := sha1.Sum([] byte(key + webSocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+	s := &Spectator{
+		conn:	conn,
+		frames:	make(chan [] byte, 1),
+		done:	make(chan struct{}),
+	}
+	ss.mu.Lock()
+	ss.spectators[s] = true
+	ss.mu.Unlock()
+	go s.Listen(rw.Reader)
+	for {
+		select {
+		case data := <-s.frames:
+			if err := s.Write(OpText, data); err != nil {
+				s.Close()
+			}
+		case <-s.done:
+			ss.mu.Lock()
+			delete(ss.spectators, s)
+			ss.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Check if anyone is watching so snapshots are only made when needed
+func (ss *SpectatorServer) Watched() bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return len(ss.spectators) > 0
+}
+
+// Send the snapshot to every spectator as JSON
+// A spectator still sending the last snapshot skips to this one
+func (ss *SpectatorServer) Broadcast(snapshot *Snapshot) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("Unable to send snapshot.")
+		return
+	}
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for s := range ss.spectators {
+		select {
+		case <-s.frames:
+		default:
+		}
+		s.frames <- data
+	}
+}
+
+// Answer pings and closes from the spectator until it goes
+func (s *Spectator) Listen(r *bufio.Reader) {
+	defer s.Close()
+	for {
+		opcode, payload, err := ReadFrame(r)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case OpClose:
+			// Echo the close so the browser sees a clean close
+			s.Write(OpClose, payload)
+			return
+		case OpPing:
+			if s.Write(OpPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
+
+// Write a frame, frames from the server are never masked
+func (s *Spectator) Write(opcode byte, payload [] byte) error {
+	header := [] byte {0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= math.MaxUint16:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.conn.Write(append(header, payload...))
+	return err
+}
+
+func (s *Spectator) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+// Read a frame sent by a browser, which masks everything it sends
+func ReadFrame(r *bufio.Reader) (opcode byte, payload [] byte, err error) {
+	var head [2] byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = head[0] & 0x0f
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2] byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8] byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxFrameSize {
+		return 0, nil, errors.New("frame too large")
+	}
+	var mask [4] byte
+	masked := head[1] & 0x80 != 0
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([] byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i % 4]
+		}
+	}
+	return opcode, payload, nil
+}
+
+// State of the game sent to spectators each tick
+// Positions are in screen pixels and angles in radians clockwise from up
+type Snapshot struct {
+	Tick		int					`json:"tick"`
+	Mode		string				`json:"mode"`
+	Width		int					`json:"width"`
+	Height		int					`json:"height"`
+	Wave		int					`json:"wave"`
+	Round		int					`json:"round,omitempty"`
+	HighScore	int					`json:"highScore"`
+	Players		[] PlayerSnapshot	`json:"players"`
+	Meteors		[] MeteorSnapshot	`json:"meteors"`
+	Missiles	[] SpriteSnapshot	`json:"missiles"`
+	Saucers		[] SpriteSnapshot	`json:"saucers"`
+	SaucerShots	[] SpriteSnapshot	`json:"saucerShots"`
+	Pickups		[] PickupSnapshot	`json:"pickups"`
+	Explosions	[] ExplosionSnapshot	`json:"explosions"`
+}
+
+type SpriteSnapshot struct {
+	X		float64		`json:"x"`
+	Y		float64		`json:"y"`
+	Angle	float64		`json:"angle"`
+	Radius	float64		`json:"radius"`
+}
+
+type PlayerSnapshot struct {
+	SpriteSnapshot
+	Alive		bool		`json:"alive"`
+	Thrusting	bool		`json:"thrusting"`
+	Shielded	bool		`json:"shielded"`	// Shield pickup or deflector up
+	Score		int			`json:"score"`
+	Lives		int			`json:"lives"`
+	Kills		int			`json:"kills"`
+	Weapon		string		`json:"weapon"`
+}
+
+type MeteorSnapshot struct {
+	SpriteSnapshot
+	Size		int				`json:"size"`
+	Outline		[] [2] float64	`json:"outline,omitempty"`	// Corners of a generated rock
+}
+
+type PickupSnapshot struct {
+	SpriteSnapshot
+	Letter	string	`json:"letter"`
+	Color	string	`json:"color"`
+}
+
+type ExplosionSnapshot struct {
+	Color		string			`json:"color"`
+	Particles	[] [3] float64	`json:"particles"`	// x, y and radius
+}
+
+// Round to a tenth of a pixel, all a viewer can use, to keep snapshots small
+func round1(v float64) float64 {
+	return math.Round(v * 10) / 10
+}
+
+func spriteSnapshot(gs *GameSprite) SpriteSnapshot {
+	return SpriteSnapshot{
+		X:		round1(gs.position.X),
+		Y:		round1(gs.position.Y),
+		Angle:	math.Round(gs.angle * 1000) / 1000,
+		Radius:	float64(gs.width) / 2,
+	}
+}
+
+// CSS colour of c
+func cssColor(c color.Color) string {
+	r, g, b, a := c.RGBA()
+	return fmt.Sprintf("rgba(%d,%d,%d,%.2f)", r >> 8, g >> 8, b >> 8, float64(a) / 0xffff)
+}
+
+// Snapshot of the game for spectators
+func (g *Game) Snapshot() *Snapshot {
+	w := g.world
+	s := &Snapshot{
+		Tick:		g.ticks,
+		Mode:		modeNames[g.game_mode],
+		Width:		ScreenWidth,
+		Height:		ScreenHeight,
+		Wave:		g.wave,
+		HighScore:	g.scoreboard.highScore,
+		Players:	[] PlayerSnapshot {},
+		Meteors:	[] MeteorSnapshot {},
+		Missiles:	[] SpriteSnapshot {},
+		Saucers:	[] SpriteSnapshot {},
+		SaucerShots:	[] SpriteSnapshot {},
+		Pickups:	[] PickupSnapshot {},
+		Explosions:	[] ExplosionSnapshot {},
+	}
+	if w.options.versus {
+		s.Round = g.round
+	}
+	for _, p := range g.players {
+		s.Players = append(s.Players, PlayerSnapshot{
+			SpriteSnapshot:	spriteSnapshot(&p.GameSprite),
+			Alive:		p.alive,
+			Thrusting:	p.sprite == p.ShipSprite(true),
+			Shielded:	p.deflecting || p.HasEffect(EffectShield),
+			Score:		p.score,
+			Lives:		p.lives,
+			Kills:		p.kills,
+			Weapon:		weapons[p.weapon].name,
+		})
+	}
+	for _, m := range w.meteors {
+		ms := MeteorSnapshot{SpriteSnapshot: spriteSnapshot(&m.GameSprite), Size: m.size}
+		for _, corner := range m.sprite.outline {
+			ms.Outline = append(ms.Outline, [2] float64 {round1(corner.X), round1(corner.Y)})
+		}
+		s.Meteors = append(s.Meteors, ms)
+	}
+	for _, m := range w.missiles {
+		s.Missiles = append(s.Missiles, spriteSnapshot(&m.GameSprite))
+	}
+	for _, saucer := range w.saucers {
+		s.Saucers = append(s.Saucers, spriteSnapshot(&saucer.GameSprite))
+	}
+	for _, shot := range w.saucerShots {
+		s.SaucerShots = append(s.SaucerShots, spriteSnapshot(&shot.GameSprite))
+	}
+	for _, p := range w.pickups {
+		s.Pickups = append(s.Pickups, PickupSnapshot{
+			SpriteSnapshot:	spriteSnapshot(&p.GameSprite),
+			Letter:	pickupLetters[p.kind],
+			Color:	cssColor(pickupColors[p.kind]),
+		})
+	}
+	for _, e := range w.explosions {
+		es := ExplosionSnapshot{Color: cssColor(e.color), Particles: [] [3] float64 {}}
+		for _, p := range e.particles {
+			if !p.done {
+				es.Particles = append(es.Particles, [3] float64 {round1(p.position.X), round1(p.position.Y), round1(p.radius)})
+			}
+		}
+		if len(es.Particles) > 0 {
+			s.Explosions = append(s.Explosions, es)
+		}
+	}
+	return s
+}
@@ -18,20 +18,20 @@ type GameSprite struct {
 	position 		vector2.Vector
 	movement		vector2.Vector
 	angle			float64
-	sprite   		*ebiten.Image
+	sprite   		*SpriteImage
 	width 			int
 	height			int
 	done			bool
 }
 
-func NewGameSprite(sprite *ebiten.Image, pos, movement vector2.Vector, angle float64) GameSprite {
+func NewGameSprite(sprite *SpriteImage, pos, movement vector2.Vector, angle float64) GameSprite {
 	return GameSprite{
 		position: pos,
 		movement: movement,
 		angle: 	  angle, 
 		sprite:   sprite,
-		width:	  sprite.Bounds().Dx(),
-		height:   sprite.Bounds().Dy(),
+		width:	  sprite.width,
+		height:   sprite.height,
 		done: 	  false,	
 	}
 }
@@ -47,10 +47,108 @@ func (gs GameSprite) DrawImage(screen  *ebiten.Image) {
 		op.GeoM.Rotate(gs.angle)
 		// move it to required position X & Y will be center of sprite as relative to 0,0
 		op.GeoM.Translate(gs.position.X , gs.position.Y)
-		screen.DrawImage(gs.sprite, op)
+		screen.DrawImage(gs.sprite.Image(), op)
 	}
 }
 
+// Draw the sprite and, if it overlaps a screen edge, copies at the
+// opposite edges so anything wrapping around the screen stays whole
+func (gs GameSprite) DrawWrapped(screen *ebiten.Image) {
+	halfW := float64(gs.width / 2)
+	halfH := float64(gs.height / 2)
+	offsetsX := [] float64 {0}
+	if gs.position.X < halfW {
+		offsetsX = append(offsetsX, ScreenWidth)
+	} else if gs.position.X > ScreenWidth - halfW {
+		offsetsX = append(offsetsX, -ScreenWidth)
+	}
+	offsetsY := [] float64 {0}
+	if gs.position.Y < halfH {
+		offsetsY = append(offsetsY, ScreenHeight)
+	} else if gs.position.Y > ScreenHeight - halfH {
+		offsetsY = append(offsetsY, -ScreenHeight)
+	}
+	for _, dx := range offsetsX {
+		for _, dy := range offsetsY {
+			ghost := gs
+			ghost.position.X += dx
+			ghost.position.Y += dy
+			ghost.DrawImage(screen)
+		}
+	}
+}
+
+// Change the image used by the sprite and its size to match
+func (gs *GameSprite) SetSprite(sprite *SpriteImage) {
+	gs.sprite = sprite
+	gs.width = sprite.width
+	gs.height = sprite.height
+}
+
+// Image from the assets with its size read from the image header
+// so the size is known for collisions without decoding the image.
+// The ebiten image is only created the first time it is drawn
+// which allows the game to run headless with no window or GPU.
+// Generated rocks have an outline instead of a name and are drawn from it.
+type SpriteImage struct {
+	name	string
+	width	int
+	height	int
+	image	*ebiten.Image
+	shape	*Shape
+	outline	[] vector2.Vector
+}
+
+// Read the size of the image requested in name
+func LoadSprite(name string) *SpriteImage {
+	f, err := assets.Open(name)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		panic(err)
+	}
+
+	return &SpriteImage{
+		name: 	name,
+		width:	config.Width,
+		height:	config.Height,
+	}
+}
+
+// Return the ebiten image loading it if not already loaded
+func (si *SpriteImage) Image() *ebiten.Image {
+	if si.image == nil {
+		if si.outline != nil {
+			si.image = RenderRock(si)
+		} else {
+			si.image = LoadImage(si.name)
+		}
+	}
+	return si.image
+}
+
+// Return the collision shape fitted to the image creating it if not
+// already made, the image is decoded without ebiten so it works headless
+func (si *SpriteImage) Shape() *Shape {
+	if si.shape == nil {
+		f, err := assets.Open(si.name)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+
+		img, _, err := image.Decode(f)
+		if err != nil {
+			panic(err)
+		}
+		si.shape = NewShape(img)
+	}
+	return si.shape
+}
 
 // Load the image requested in name
 func LoadImage(name string) *ebiten.Image {
@@ -93,9 +191,13 @@ func (my ScreenPos) Intersects(other ScreenPos) bool {
 			(math.Abs(my.Y - other.Y) < float64(my.Height) / 2 + float64(other.Height) / 2)
 }
 
-// Check if distance between 2 screen positions is less than sum of radiuses (radius = half with)
-// 2 pixel margin deducted so slight overlap
-func (my ScreenPos) Collides(other ScreenPos) bool {
-	distance := math.Sqrt((other.X - my.X) * (other.X - my.X) + (other.Y - my.Y) * (other.Y - my.Y))
-	return distance < float64(my.Width) / 2 + float64(other.Width) / 2 - 2	
+// Shortest distance d across a wrapped screen of the size given
+func WrapDelta(d, size float64) float64 {
+	return d - size * math.Round(d / size)
+}
+
+// Move a position that has gone off the screen to the opposite edge
+func WrapPosition(pos *vector2.Vector) {
+	pos.X -= ScreenWidth * math.Floor(pos.X / ScreenWidth)
+	pos.Y -= ScreenHeight * math.Floor(pos.Y / ScreenHeight)
 }
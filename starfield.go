@@ -7,7 +7,6 @@ package main
 
 import (
 	"image/color"
-	"math/rand/v2"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 	"github.com/paul63/vector2"
@@ -15,52 +14,51 @@ import (
 
 const (
 	NumStars = 50
-	StarSpeed = 0.25
+	StarSpeed = 0.5
 )
 
-var stars [] *Star
-
 var star_white color.Color = color.RGBA{125, 125, 125, 75}
 
-func CreateStarField() {
-	if len(stars) != 0 {
+func CreateStarField(w *World) {
+	if len(w.stars) != 0 {
 		// clear existing stars
-		stars = nil
+		w.stars = nil
 	}
 	for i := 0; i < NumStars; i++ {
-		s := NewStar(
-			float64(rand.IntN(ScreenWidth)),
-			float64(rand.IntN(ScreenHeight)),
-			rand.IntN(3) + 1)
-		stars = append(stars, s)
+		NewStar(w,
+			float64(w.starRng.IntN(ScreenWidth)),
+			float64(w.starRng.IntN(ScreenHeight)),
+			w.starRng.IntN(3) + 1)
 	}
 }
 
-func UpdateStars(){
-	for _, s := range(stars) {
+func (w *World) UpdateStars(){
+	for _, s := range(w.stars) {
 		s.Update()
 	}
 }
 
-func DrawStars(screen *ebiten.Image){
-	for _, s := range(stars) {
+func (w *World) DrawStars(screen *ebiten.Image){
+	for _, s := range(w.stars) {
 		s.Draw(screen)
 	}
 }
 
 type Star struct {
+	world		*World
 	position	vector2.Vector
 	velocity 	vector2.Vector
 	radius		int
 }
 
-func NewStar(x, y float64, size int) *Star{
+func NewStar(w *World, x, y float64, size int) *Star{
 	s := Star{
+		world: w,
 		position: vector2.Vector{X: x, Y: y},
 		velocity: vector2.Vector{X: 0, Y:StarSpeed},
 		radius: size,
 	}
-	stars = append(stars, &s)
+	w.stars = append(w.stars, &s)
 	return &s
 }
 
@@ -73,7 +71,7 @@ func (s *Star) Update() {
 	s.position.Add(s.velocity)
 	if s.position.Y > ScreenHeight {
 		s.position.Y = 0
-		s.position.X = float64(rand.IntN(ScreenWidth))
+		s.position.X = float64(s.world.starRng.IntN(ScreenWidth))
 	}
 }
 
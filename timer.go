@@ -1,76 +1,136 @@
 // Timer struct and methods
 // for games written in Go using Ebitengine
-// call UpdateAllTimers once every frame
+// call World.UpdateAllTimers once every frame
 // Author Paul Brace
 // July 2024
+// Updated to keep time in fixed point units so fractional seconds are not
+//   lost and to add a manager to pause, scale and remove timers.
 
 package main
 
 import (
+	"math"
+
 	"github.com/hajimehoshi/ebiten/v2"
-	"time"
 )
 
-var timers [] *Timer
+// Time is counted in fixed point units, one tick at normal speed is
+// TimerUnitsPerTick units so a tenth of a second is still 6000 units at 60 TPS
+const TimerUnitsPerTick = 1000
+
+// Convert seconds to timer units rounding to the nearest unit
+func secondsToUnits(seconds float64) int64 {
+	return int64(math.Round(seconds * float64(ebiten.TPS()) * TimerUnitsPerTick))
+}
+
+// Holds every timer so they can be updated, paused and scaled together
+type TimerManager struct {
+	timers	[] *Timer
+	paused	bool
+	scale	int64	// Units added each tick, TimerUnitsPerTick is normal speed
+}
+
+func NewTimerManager() *TimerManager {
+	return &TimerManager{scale: TimerUnitsPerTick}
+}
+
+// Advance all timers by one tick removing any that have been cancelled (call once every frame)
+func (tm *TimerManager) Update() {
+	for i := 0; i < len(tm.timers); {
+		t := tm.timers[i]
+		if t.cancelled {
+			tm.timers = append(tm.timers[:i], tm.timers[i+1:]...)
+			continue
+		}
+		if !tm.paused {
+			t.Update(tm.scale)
+		}
+		i++
+	}
+}
+
+// Stop all timers advancing until Resume is called
+func (tm *TimerManager) Pause() {
+	tm.paused = true
+}
 
-func UpdateAllTimers(){
-	for _, t := range timers {
-		t.Update()
-	} 
+func (tm *TimerManager) Resume() {
+	tm.paused = false
 }
 
-// Timer struct for use in games ticks updated every frame
+// Change the speed timers run at, 1 is normal and 0.5 half speed
+func (tm *TimerManager) SetScale(scale float64) {
+	tm.scale = int64(math.Round(scale * TimerUnitsPerTick))
+}
+
+func (w *World) UpdateAllTimers(){
+	w.timers.Update()
+}
+
+// Timer struct for use in games units updated every frame
 type Timer struct {
-	currentTicks 	int
-	targetTicks  	int
+	current 		int64
+	target  		int64
 	active 			bool
 	repeat			bool
+	cancelled		bool
 }
 
 // Creates a new timer if repeating is false then one off if true the triggers every Duration
-func NewTimer(seconds float64, repeating bool) *Timer {
-	d := time.Duration(seconds) * time.Second
+// The timer is added to the world so it is updated every frame
+func NewTimer(w *World, seconds float64, repeating bool) *Timer {
 	timer := Timer{
-		currentTicks: 	0,
-		targetTicks:  	int(d.Milliseconds()) * ebiten.TPS() / 1000,
+		current: 		0,
+		target:  		secondsToUnits(seconds),
 		active: 		true,
 		repeat:			repeating,
 	}
-	timers = append(timers, &timer)
+	w.timers.timers = append(w.timers.timers, &timer)
 	return &timer
 }
 
-// Updates a single timer (call every frame)
-func (t *Timer) Update() {
-	if t.active{
-		if t.currentTicks < t.targetTicks {
-			t.currentTicks++
-		}
+// Updates a single timer by the units given (called every frame by the manager)
+func (t *Timer) Update(units int64) {
+	if t.active && t.current < t.target {
+		t.current += units
 	}
 }
 
 // Checks if the timer has expired and if it has reset it
 func (t *Timer) IsReady() bool {
-	hasExpired := t.active && t.currentTicks >= t.targetTicks
+	hasExpired := t.active && t.current >= t.target
 	if hasExpired {
 		t.Reset()
 	}
 	return hasExpired
 }
 
+// Checks if the timer is still counting down
+func (t *Timer) Running() bool {
+	return t.active && t.current < t.target
+}
+
 // Called to reset for repeating timers or deactivate for one off
 func (t *Timer) Reset() {
-	t.currentTicks = 0
+	t.current = 0
 	t.active = t.repeat
 }
 
+// Stop the timer until it is Reset or its time changed
+func (t *Timer) Stop() {
+	t.active = false
+}
+
+// Stop the timer and remove it from the manager on the next update
+func (t *Timer) Cancel() {
+	t.active = false
+	t.cancelled = true
+}
+
 // function to allow the timer interval to be changed
 func (t *Timer) ChangeTime(seconds float64, repeating bool) {
-	d := time.Duration(seconds) * time.Second
-	t.currentTicks = 0
-	t.targetTicks =	int(d.Milliseconds()) * ebiten.TPS() / 1000
+	t.current = 0
+	t.target = secondsToUnits(seconds)
 	t.active = true
 	t.repeat = repeating
 }
-
-
@@ -0,0 +1,108 @@
+package main
+
+import "testing"
+
+// Ticks a new timer takes to be ready with the manager changed by setup
+// before it starts, or -1 if it is not ready within limit ticks
+func ticksToReady(seconds float64, setup func(w *World, t *Timer), limit int) int {
+	w := NewWorld(DefaultOptions(), 1)
+	t := NewTimer(w, seconds, false)
+	setup(w, t)
+	for tick := 1; tick <= limit; tick++ {
+		w.UpdateAllTimers()
+		if t.IsReady() {
+			return tick
+		}
+	}
+	return -1
+}
+
+func TestTimerTicks(t *testing.T) {
+	for _, test := range [] struct {
+		name	string
+		seconds	float64
+		setup	func(w *World, t *Timer)
+		ticks	int
+	}{
+		{"whole second", 1, func(w *World, t *Timer) {}, 60},
+		{"tenth of a second", 0.1, func(w *World, t *Timer) {}, 6},
+		{"half speed", 0.1, func(w *World, t *Timer) { w.timers.SetScale(0.5) }, 12},
+		{"quarter speed", 0.1, func(w *World, t *Timer) { w.timers.SetScale(0.25) }, 24},
+		{"paused", 0.1, func(w *World, t *Timer) { w.timers.Pause() }, -1},
+		{"stopped", 0.1, func(w *World, t *Timer) { t.Stop() }, -1},
+		{"changed", 1, func(w *World, t *Timer) { t.ChangeTime(0.5, false) }, 30},
+	} {
+		if ticks := ticksToReady(test.seconds, test.setup, 200); ticks != test.ticks {
+			t.Errorf("%s: ready after %d ticks, want %d", test.name, ticks, test.ticks)
+		}
+	}
+}
+
+func TestTimerUnits(t *testing.T) {
+	if units := secondsToUnits(0.1); units != 6 * TimerUnitsPerTick {
+		t.Errorf("a tenth of a second is %d units", units)
+	}
+}
+
+func TestTimerPauseAndResume(t *testing.T) {
+	w := NewWorld(DefaultOptions(), 1)
+	timer := NewTimer(w, 0.1, false)
+	for i := 0; i < 3; i++ {
+		w.UpdateAllTimers()
+	}
+	w.timers.Pause()
+	for i := 0; i < 100; i++ {
+		w.UpdateAllTimers()
+	}
+	w.timers.Resume()
+	for i := 0; i < 2; i++ {
+		w.UpdateAllTimers()
+	}
+	if !timer.Running() {
+		t.Fatal("timer ran while paused")
+	}
+	w.UpdateAllTimers()
+	if !timer.IsReady() {
+		t.Error("timer not ready after 6 unpaused ticks")
+	}
+}
+
+func TestTimerRepeats(t *testing.T) {
+	for _, test := range [] struct {
+		repeating	bool
+		ready		int
+	}{
+		{false, 1},
+		{true, 5},
+	} {
+		w := NewWorld(DefaultOptions(), 1)
+		timer := NewTimer(w, 0.1, test.repeating)
+		ready := 0
+		for i := 0; i < 30; i++ {
+			w.UpdateAllTimers()
+			if timer.IsReady() {
+				ready++
+			}
+		}
+		if ready != test.ready {
+			t.Errorf("repeating %t: ready %d times in 30 ticks, want %d", test.repeating, ready, test.ready)
+		}
+	}
+}
+
+func TestTimerCancel(t *testing.T) {
+	w := NewWorld(DefaultOptions(), 1)
+	keep := NewTimer(w, 0.1, true)
+	gone := NewTimer(w, 0.1, true)
+	gone.Cancel()
+	w.UpdateAllTimers()
+	if len(w.timers.timers) != 1 || w.timers.timers[0] != keep {
+		t.Fatalf("%d timers left after cancelling one of two", len(w.timers.timers))
+	}
+	for i := 0; i < 10; i++ {
+		w.UpdateAllTimers()
+	}
+	if gone.IsReady() {
+		t.Error("cancelled timer became ready")
+	}
+}
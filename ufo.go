@@ -0,0 +1,252 @@
+// Flying saucer struct and methods
+// for Asteroids written in Go using Ebitengine
+// Saucers cross the screen from one side to the other zig-zagging as they go
+// and firing shots, large saucers fire in any direction while small ones aim
+// at the player.
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/paul63/vector2"
+)
+
+// Saucer sizes
+const (
+	SaucerLarge = 0
+	SaucerSmall = 1
+)
+
+const (
+	SaucerTime = 20			// Seconds between saucers appearing
+	SaucerTurnTime = 1		// Least seconds before a saucer changes direction
+	SaucerShotSpeed = 4.0
+	SaucerShotRange = 500	// Distance a shot travels before it is gone
+	SaucerAimError = 0.15	// Largest error in radians when a small saucer aims
+)
+
+var (
+	saucerSprites = [] *SpriteImage {LoadSprite("assets/ufoLarge.png"),
+									LoadSprite("assets/ufoSmall.png")}
+	saucerShotSprite = LoadSprite("assets/ufoShot.png")
+	saucerScores = [] int {200, 1000}
+	saucerSpeeds = [] float64 {1.5, 2.5}
+	saucerFireTimes = [] float64 {1.5, 1.2}	// Seconds between shots
+	saucerColor color.Color = color.RGBA{125, 200, 255, 100}
+)
+
+func (w *World) UpdateAllSaucers(){
+	for _, s := range w.saucers {
+		s.Update()
+	}
+}
+
+func (w *World) DrawAllSaucers(screen *ebiten.Image){
+	for _, s := range w.saucers {
+		s.Draw(screen)
+	}
+}
+
+func (w *World) ClearDoneSaucers(){
+	for i, s := range(w.saucers){
+		if s.done{
+			s.CancelTimers()
+			w.saucers = append(w.saucers[:i], w.saucers[i+1:]...)
+			break
+		}
+	}
+}
+
+func (w *World) ClearAllSaucers(){
+	for _, s := range w.saucers {
+		s.CancelTimers()
+	}
+	w.saucers = nil
+}
+
+type Saucer struct {
+	GameSprite
+	world		*World
+	target		*Player		// Small saucers aim at the target
+	size		int
+	turnTimer	*Timer		// Timers run slower in slow time as the saucer does
+	fireTimer	*Timer
+}
+
+// Choose the size of the next saucer, small saucers become more likely as the score rises
+func SaucerSize(w *World, score int) int {
+	if w.rng.Float64() < math.Min(0.2 + float64(score) / 20000, 0.8) {
+		return SaucerSmall
+	}
+	return SaucerLarge
+}
+
+// Create a new saucer of size entering from the left or right edge
+// and add to the world's list
+func NewSaucer(w *World, size int) *Saucer {
+	sprite := saucerSprites[size]
+	player := w.TargetPlayer()
+	speed := saucerSpeeds[size]
+	pos := vector2.Vector{
+		X: -float64(sprite.width / 2),
+		Y: ScreenHeight * (0.1 + w.rng.Float64() * 0.8),
+	}
+	movement := vector2.Vector{X: speed, Y: 0}
+	if w.rng.IntN(2) == 1 {
+		pos.X = ScreenWidth + float64(sprite.width / 2)
+		movement.X = -speed
+	}
+
+	saucer := Saucer{
+		GameSprite: NewGameSprite(sprite, pos, movement, 0),
+		world:		w,
+		target:		player,
+		size:		size,
+		turnTimer:	NewTimer(w, SaucerTurnTime * (1 + w.rng.Float64()), false),
+		fireTimer:	NewTimer(w, saucerFireTimes[size], true),
+	}
+	// Add to world's list of saucers
+	w.saucers = append(w.saucers, &saucer)
+
+	return &saucer
+}
+
+func (s *Saucer) Update() {
+	if !s.done {
+		step := s.movement
+		step.MultiplyByScalar(s.world.timeScale)
+		s.position.Add(step)
+		// Zig-zag by changing to move up, down or straight across
+		if s.turnTimer.IsReady() {
+			s.movement.Y = float64(s.world.rng.IntN(3) - 1) * math.Abs(s.movement.X) * 0.75
+			s.turnTimer.ChangeTime(SaucerTurnTime * (1 + s.world.rng.Float64()), false)
+		}
+		// Stay on the screen vertically
+		halfH := float64(s.height / 2)
+		if s.world.options.wrap {
+			s.position.Y -= ScreenHeight * math.Floor(s.position.Y / ScreenHeight)
+		} else if s.position.Y < halfH || s.position.Y > ScreenHeight - halfH {
+			s.movement.Y = -s.movement.Y
+			s.position.Y = math.Max(halfH, math.Min(s.position.Y, ScreenHeight - halfH))
+		}
+		// Gone once it has crossed the screen
+		s.done = s.position.X < -float64(s.width) || s.position.X > ScreenWidth + float64(s.width)
+		if !s.done && s.fireTimer.IsReady() {
+			s.Fire()
+		}
+	}
+}
+
+// Remove the saucer's timers from the world once it has gone
+func (s *Saucer) CancelTimers() {
+	s.turnTimer.Cancel()
+	s.fireTimer.Cancel()
+}
+
+// Fire a shot at the player if small or in a random direction if large
+func (s *Saucer) Fire() {
+	var angle float64
+	if !s.target.alive {
+		s.target = s.world.TargetPlayer()
+	}
+	if s.size == SaucerSmall && s.target.alive {
+		angle = s.position.PointTowards(s.target.position) +
+			(s.world.rng.Float64() * 2 - 1) * SaucerAimError
+	} else {
+		angle = s.world.rng.Float64() * 2 * math.Pi
+	}
+	NewSaucerShot(s.world, s.position, angle)
+}
+
+// Saucers never wrap across the sides so have no copies at the opposite edge
+func (s *Saucer) Draw(screen *ebiten.Image) {
+	s.DrawImage(screen)
+}
+
+// Process saucer being hit and return score based on size
+func (s *Saucer) Hit(explode bool) int {
+	if s.done {
+		return 0
+	}
+	s.done = true
+	if explode {
+		NewExplosion(s.world, s.position.X, s.position.Y, 40,
+			saucerColor, 0.04)
+	}
+	return saucerScores[s.size]
+}
+
+func (w *World) UpdateAllSaucerShots(){
+	for _, s := range w.saucerShots {
+		s.Update()
+	}
+}
+
+func (w *World) DrawAllSaucerShots(screen *ebiten.Image){
+	for _, s := range w.saucerShots {
+		s.Draw(screen)
+	}
+}
+
+func (w *World) ClearDoneSaucerShots(){
+	for i, s := range(w.saucerShots){
+		if s.done{
+			w.saucerShots = append(w.saucerShots[:i], w.saucerShots[i+1:]...)
+			break
+		}
+	}
+}
+
+func (w *World) ClearAllSaucerShots(){
+	w.saucerShots = nil
+}
+
+type SaucerShot struct {
+	GameSprite
+	world		*World
+	travelled	float64
+}
+
+// Create a shot fired from pos in the direction of angle
+func NewSaucerShot(w *World, pos vector2.Vector, angle float64) *SaucerShot {
+	movement := vector2.Vector{
+		X: math.Sin(angle) * SaucerShotSpeed,
+		Y: math.Cos(angle) * -SaucerShotSpeed,
+	}
+	shot := SaucerShot{
+		GameSprite: NewGameSprite(saucerShotSprite, pos, movement, angle),
+		world:		w,
+	}
+	// Add to world's list of saucer shots
+	w.saucerShots = append(w.saucerShots, &shot)
+
+	return &shot
+}
+
+func (s *SaucerShot) Update() {
+	if !s.done {
+		step := s.movement
+		step.MultiplyByScalar(s.world.timeScale)
+		s.position.Add(step)
+		s.travelled += SaucerShotSpeed * s.world.timeScale
+		if s.world.options.wrap {
+			WrapPosition(&s.position)
+			s.done = s.travelled > SaucerShotRange
+		} else {
+			s.done = s.travelled > SaucerShotRange ||
+				s.position.X < -50 || s.position.X > ScreenWidth + 50 ||
+				s.position.Y < -50 || s.position.Y > ScreenHeight + 50
+		}
+	}
+}
+
+func (s *SaucerShot) Draw(screen *ebiten.Image) {
+	if s.world.options.wrap {
+		s.DrawWrapped(screen)
+	} else {
+		s.DrawImage(screen)
+	}
+}
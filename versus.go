@@ -0,0 +1,149 @@
+// Versus mode
+// for Asteroids written in Go using Ebitengine
+// Two ships try to shoot each other down while the asteroids and saucers
+// are a danger to both. Each round ends when a ship is destroyed and the
+// first player to the number of kills set by the options wins.
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	RoundOverTicks = 300		// Ticks the scores are shown between rounds
+	RoundSkipTicks = 60			// Ticks before fire skips to the next round
+)
+
+// Clear the field and put both ships back at their start for the next round
+func (g *Game) StartRound() {
+	g.round += 1
+	g.scoreboard.round = g.round
+	for _, p := range g.players {
+		p.Reset()
+		p.ResetWeapons()
+		// Stop the ship firing for the reload period so skipping the scores does not fire
+		p.loaded = false
+		p.reloadTimer = reloadTime
+		p.respawnTimer.Stop()
+	}
+	g.StartWave(1)
+	g.game_mode = InPlay
+}
+
+// Check if the player has been hit by a missile fired by the other player
+// Returns true if the ship was destroyed, with a shield the missile is destroyed instead
+// Every missile is checked as a grid costs more to build than it saves for one ship
+func (g *Game) CheckShot(player *Player, shield bool) bool {
+	for _, miss := range g.world.missiles {
+		if miss.owner != player && g.world.MissileHits(miss, &player.GameSprite) {
+			miss.done = true
+			if !shield {
+				g.PlayerHit(player, miss.owner)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Once a ship has been destroyed and its explosion has finished either
+// show the scores before the next round or end the game if there is a winner
+// (called every tick while a versus game is in play)
+func (g *Game) UpdateRound() {
+	if g.world.PlayersAlive() == len(g.players) {
+		return
+	}
+	for _, p := range g.players {
+		if p.respawnTimer.Running() {
+			return
+		}
+	}
+	if g.Winner() != nil {
+		g.End()
+		return
+	}
+	g.game_mode = RoundOver
+	g.roundOverTicks = RoundOverTicks
+}
+
+// Count down the time between rounds, any player holding fire starts the
+// next round early. The input is read every tick, as it is in play, so
+// a replay has the input for every update.
+func (g *Game) UpdateRoundOver(inputs [] InputState) {
+	g.ticks++
+	g.roundOverTicks -= 1
+	skip := false
+	for _, in := range inputs {
+		skip = skip || in.Held(ActionFire)
+	}
+	if g.roundOverTicks <= 0 || (skip && g.roundOverTicks < RoundOverTicks - RoundSkipTicks) {
+		g.StartRound()
+	}
+}
+
+// The player with the most kills once they have enough to win,
+// nil if nobody has won yet or the leaders are level
+func (g *Game) Winner() *Player {
+	var winner *Player
+	level := false
+	for _, p := range g.players {
+		switch {
+		case winner == nil || p.kills > winner.kills:
+			winner = p
+			level = false
+		case p.kills == winner.kills:
+			level = true
+		}
+	}
+	if level || winner.kills < g.world.options.kills {
+		return nil
+	}
+	return winner
+}
+
+// How the player's ship was destroyed in the round just finished
+func (p *Player) RoundResult() string {
+	switch {
+	case p.alive:
+		return fmt.Sprintf("Player %d survived", p.number + 1)
+	case p.killedBy != nil:
+		return fmt.Sprintf("Player %d was shot down by player %d", p.number + 1, p.killedBy.number + 1)
+	}
+	return fmt.Sprintf("Player %d was destroyed", p.number + 1)
+}
+
+// Show how the round ended and the kills of each player over the game
+func (sb *ScoreBoard) DrawRoundOver(screen *ebiten.Image, players [] *Player, round int){
+	vector.DrawFilledRect(screen, 0, 0, ScreenWidth, ScreenHeight, color.RGBA{0, 0, 0, 160}, false)
+	sb.DrawCenter(screen, fmt.Sprintf("End of round %d", round), ScreenWidth/2, 200, 40, yellow)
+	for i, p := range players {
+		sb.DrawCenter(screen, p.RoundResult(), ScreenWidth/2, 300 + i * 40, 24, white)
+		sb.DrawCenter(screen, fmt.Sprintf("Player %d: %d kills", i + 1, p.kills), ScreenWidth/2, 420 + i * 50, 30, aqua)
+	}
+	sb.DrawCenter(screen, fmt.Sprintf("First to %d kills wins", sb.killsToWin), ScreenWidth/2, 560, 24, white)
+	sb.DrawCenter(screen, "Fire to start the next round", ScreenWidth/2, 640, 20, white)
+}
+
+// Show who won in place of the game over screen
+func (sb *ScoreBoard) DrawWinner(screen *ebiten.Image, b *Bindings, players [] *Player, rounds int){
+	sb.DrawCenter(screen, "Asteroids - Versus", ScreenWidth/2, 20, 40, yellow)
+	winner := players[0]
+	for _, p := range players {
+		if p.kills > winner.kills {
+			winner = p
+		}
+	}
+	sb.DrawCenter(screen, fmt.Sprintf("Player %d wins", winner.number + 1), ScreenWidth/2, 200, 60, green)
+	for i, p := range players {
+		sb.DrawCenter(screen, fmt.Sprintf("Player %d: %d kills  Score: %06d", i + 1, p.kills, p.score),
+			ScreenWidth/2, 360 + i * 50, 30, white)
+	}
+	sb.DrawCenter(screen, fmt.Sprintf("Rounds played: %d", rounds), ScreenWidth/2, 500, 24, white)
+	sb.DrawCenter(screen, fmt.Sprintf("Seed: %d", sb.seed), ScreenWidth/2, 640, 20, white)
+	sb.DrawCenter(screen, fmt.Sprintf("Press %s to play again", b.Names(ActionStart)), ScreenWidth/2, 700, 30, aqua)
+}
@@ -0,0 +1,215 @@
+// Waves of asteroids
+// for Asteroids written in Go using Ebitengine
+// Each wave starts with a set of asteroids and ends once they have all been
+// destroyed. The waves are read from a text file so they can be tuned
+// without changing the game, see assets/waves.txt for the format.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultWaves = "assets/waves.txt"	// Built in waves used when no file is given
+	WaveGap = 2							// Seconds between one wave being cleared and the next
+	WaveBannerTicks = 120				// Ticks the wave number is shown at the start of a wave
+)
+
+// Sizes of asteroid as named in the waves file, index is the meteor size
+var waveSizeNames = [] string {"tiny", "small", "medium", "large"}
+
+type WaveSpec struct {
+	meteors		[4] int		// Asteroids the wave starts with by size
+	speed		float64		// Multiplies the speed of every asteroid
+	extra		int			// Asteroids that arrive during the wave
+	gap			float64		// Seconds between extra asteroids
+}
+
+func NewWaveSpec() WaveSpec {
+	return WaveSpec{speed: 1, gap: StartSpawnTime}
+}
+
+// Read waves, one per line, each given as name=value pairs
+// e.g. "large=3 medium=1 speed=1.2 extra=2 gap=3"
+// Blank lines and lines starting with # are ignored.
+func ReadWaves(r io.Reader) ([] WaveSpec, error) {
+	var waves [] WaveSpec
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		wave := NewWaveSpec()
+		for _, field := range fields {
+			name, value, found := strings.Cut(field, "=")
+			if !found {
+				return nil, fmt.Errorf("line %d: %q has no value", line, field)
+			}
+			if err := wave.Set(name, value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		waves = append(waves, wave)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(waves) == 0 {
+		return nil, fmt.Errorf("no waves")
+	}
+	return waves, nil
+}
+
+// Set the value called name from its text
+func (ws *WaveSpec) Set(name, value string) error {
+	for size, n := range waveSizeNames {
+		if n == name {
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 {
+				return fmt.Errorf("invalid number of %s asteroids %q", name, value)
+			}
+			ws.meteors[size] = count
+			return nil
+		}
+	}
+	var err error
+	switch name {
+	case "speed":
+		ws.speed, err = strconv.ParseFloat(value, 64)
+		if err == nil && !positive(ws.speed) {
+			err = fmt.Errorf("speed must be more than 0")
+		}
+	case "extra":
+		ws.extra, err = strconv.Atoi(value)
+		if err == nil && ws.extra < 0 {
+			err = fmt.Errorf("extra cannot be less than 0")
+		}
+	case "gap":
+		ws.gap, err = strconv.ParseFloat(value, 64)
+		if err == nil && !positive(ws.gap) {
+			err = fmt.Errorf("gap must be more than 0")
+		}
+	default:
+		return fmt.Errorf("unknown setting %q", name)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+	return nil
+}
+
+// The wave as name=value pairs in the form read by ReadWaves
+func (ws WaveSpec) String() string {
+	var fields [] string
+	for size := len(ws.meteors) - 1; size >= 0; size-- {
+		if ws.meteors[size] > 0 {
+			fields = append(fields, fmt.Sprintf("%s=%d", waveSizeNames[size], ws.meteors[size]))
+		}
+	}
+	fields = append(fields, fmt.Sprintf("speed=%v extra=%d gap=%v", ws.speed, ws.extra, ws.gap))
+	return strings.Join(fields, " ")
+}
+
+// Waves as the lines of a waves file, as saved in replays and sent to the
+// other player of a network game
+func WavesText(waves [] WaveSpec) string {
+	var lines [] string
+	for _, wave := range waves {
+		lines = append(lines, wave.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Check a value is a number more than 0 that is not infinite
+func positive(v float64) bool {
+	return v > 0 && !math.IsInf(v, 1)
+}
+
+// Load the waves from the file called name or the built in waves if
+// name is empty or the file cannot be read
+func LoadWaves(name string) [] WaveSpec {
+	if name != "" {
+		f, err := os.Open(name)
+		if err == nil {
+			defer f.Close()
+			var waves [] WaveSpec
+			waves, err = ReadWaves(f)
+			if err == nil {
+				return waves
+			}
+		}
+		fmt.Println(err)
+		fmt.Println("Unable to read waves, using the built in waves.")
+	}
+	f, err := assets.Open(DefaultWaves)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	waves, err := ReadWaves(f)
+	if err != nil {
+		panic(err)
+	}
+	return waves
+}
+
+// Wave number n counting from 1, once past the last wave it is repeated
+// with another large asteroid and 10% more speed each time
+func (g *Game) WaveSpec(n int) WaveSpec {
+	if n <= len(g.waves) {
+		return g.waves[n - 1]
+	}
+	wave := g.waves[len(g.waves) - 1]
+	beyond := n - len(g.waves)
+	wave.meteors[3] += beyond
+	wave.speed *= 1 + 0.1 * float64(beyond)
+	return wave
+}
+
+// Clear the field and start wave number n with its asteroids
+func (g *Game) StartWave(n int) {
+	g.wave = n
+	g.scoreboard.wave = n
+	spec := g.WaveSpec(n)
+	g.world.Clear()
+	g.world.meteorSpeed = spec.speed
+	for size := len(spec.meteors) - 1; size >= 0; size-- {
+		for i := 0; i < spec.meteors[size]; i++ {
+			NewSizedMeteor(g.world, size)
+		}
+	}
+	g.extraMeteors = spec.extra
+	g.spawnTimer.ChangeTime(spec.gap, true)
+	g.waveTimer.Stop()
+	g.waveCleared = false
+	g.bannerTicks = WaveBannerTicks
+}
+
+// Bring in the extra asteroids and start the next wave once the field is clear
+// (called every tick while the game is in play)
+func (g *Game) UpdateWave() {
+	if g.bannerTicks > 0 {
+		g.bannerTicks -= 1
+	}
+	if g.spawnTimer.IsReady() && g.extraMeteors > 0 {
+		NewMeteor(g.world)
+		g.extraMeteors -= 1
+	}
+	if g.world.PlayersAlive() > 0 && !g.waveCleared && g.extraMeteors == 0 && g.world.MeteorsLeft() == 0 {
+		g.waveCleared = true
+		g.waveTimer.ChangeTime(WaveGap, false)
+	}
+	if g.waveTimer.IsReady() {
+		g.StartWave(g.wave + 1)
+	}
+}
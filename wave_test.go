@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadWavesRejectsBadSettings(t *testing.T) {
+	for _, line := range [] string {
+		"large=-1",
+		"large=2 speed=0",
+		"large=2 speed=-1.5",
+		"large=2 speed=NaN",
+		"large=2 extra=-1",
+		"large=2 gap=0",
+		"large=2 gap=+Inf",
+		"large=2 colour=red",
+		"large",
+	} {
+		if _, err := ReadWaves(strings.NewReader(line)); err == nil {
+			t.Errorf("%q was accepted", line)
+		}
+	}
+}
+
+func TestWaveSpecRoundTrip(t *testing.T) {
+	waves := LoadWaves("")
+	var lines [] string
+	for _, wave := range waves {
+		lines = append(lines, wave.String())
+	}
+	read, err := ReadWaves(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, waves) {
+		t.Errorf("wrote %v read %v", waves, read)
+	}
+}
@@ -0,0 +1,134 @@
+// Weapons
+// for Asteroids written in Go using Ebitengine
+// Each weapon has its own fire rate, damage, missile sprite and ammunition.
+// The standard weapon never runs out, the others are filled at the start of
+// each game and by ammo pickups.
+
+package main
+
+import (
+	"slices"
+)
+
+const (
+	WeaponStandard = iota
+	WeaponSpread
+	WeaponLaser
+	WeaponHoming
+	WeaponMine
+	NumWeapons
+)
+
+// How missiles move once fired
+const (
+	MissileStraight = iota	// Flies in a straight line
+	MissileHoming			// Steers towards the nearest meteor
+	MissileMine				// Stays where it is dropped
+	MissileBeam				// Hits everything along a line the tick it is fired
+)
+
+const (
+	Unlimited = -1			// Ammo of a weapon that never runs out
+	RapidReloadScale = 0.4	// Fraction of the reload time with rapid fire
+)
+
+type Weapon struct {
+	name		string
+	reload		int				// Ticks between shots
+	damage		int				// Sizes knocked off a meteor by each missile
+	speed		float64
+	sprite		*SpriteImage
+	ammo		int				// Shots given at the start of a game, Unlimited if it never runs out
+	shots		int				// Missiles fired at once, spread by SpreadAngle
+	pierce		bool			// Missiles pass through meteors
+	behaviour	int
+	life		int				// Ticks a mine or homing missile lasts or a beam is shown
+	offset		float64			// Distance in front of the ship missiles appear
+}
+
+var weapons = [NumWeapons] *Weapon {
+	{name: "Standard", reload: 15, damage: 1, speed: 5, sprite: missileSprite,
+		ammo: Unlimited, shots: 1, behaviour: MissileStraight, offset: 20},
+	{name: "Spread", reload: 20, damage: 1, speed: 5, sprite: missileSprite,
+		ammo: 40, shots: 3, behaviour: MissileStraight, offset: 20},
+	{name: "Laser", reload: 25, damage: 1, sprite: LoadSprite("assets/laser.png"),
+		ammo: 30, shots: 1, pierce: true, behaviour: MissileBeam, life: 8, offset: 30},
+	{name: "Homing", reload: 30, damage: 2, speed: 4, sprite: LoadSprite("assets/homing.png"),
+		ammo: 20, shots: 1, behaviour: MissileHoming, life: 240, offset: 20},
+	{name: "Mine", reload: 40, damage: 3, speed: 0, sprite: LoadSprite("assets/mine.png"),
+		ammo: 10, shots: 1, behaviour: MissileMine, life: 600, offset: -20},
+}
+
+// Fill every weapon with its starting ammo and select the standard weapon
+// (called at the start of each game)
+func (p *Player) ResetWeapons() {
+	for i, weapon := range weapons {
+		p.ammo[i] = weapon.ammo
+	}
+	p.weapon = WeaponStandard
+}
+
+// Add the starting ammo to every weapon that can run out
+func (p *Player) AddAmmo() {
+	for i, weapon := range weapons {
+		if weapon.ammo != Unlimited {
+			p.ammo[i] += weapon.ammo
+		}
+	}
+}
+
+// Change to weapon n if it has any ammo left
+func (p *Player) SelectWeapon(n int) {
+	if n >= 0 && n < NumWeapons && p.ammo[n] != 0 {
+		p.weapon = n
+	}
+}
+
+// Move through the weapons in the direction of step skipping any without ammo
+func (p *Player) CycleWeapon(step int) {
+	for n := (p.weapon + step + NumWeapons) % NumWeapons; n != p.weapon; n = (n + step + NumWeapons) % NumWeapons {
+		if p.ammo[n] != 0 {
+			p.weapon = n
+			return
+		}
+	}
+}
+
+// Change weapon from a number key or once each time next or previous is pressed
+func (p *Player) UpdateWeapon(in InputState) {
+	if in.weapon > 0 {
+		p.SelectWeapon(in.weapon - 1)
+	}
+	next, prev := in.Held(ActionNextWeapon), in.Held(ActionPrevWeapon)
+	if next && !p.switchHeld {
+		p.CycleWeapon(1)
+	} else if prev && !p.switchHeld {
+		p.CycleWeapon(-1)
+	}
+	p.switchHeld = next || prev
+}
+
+// Current weapon
+func (p *Player) Weapon() *Weapon {
+	return weapons[p.weapon]
+}
+
+// Hit the meteor with the missile for the damage of its weapon and return the score
+// A piercing missile carries on but never hits the same meteor, or its fragments, again
+func (m *Missile) HitMeteor(met *Meteor) int {
+	if slices.Contains(m.struck, met) {
+		return 0
+	}
+	before := len(m.world.meteors)
+	score := met.Damage(m.weapon.damage, true)
+	if m.weapon.pierce {
+		m.struck = append(m.struck, met)
+		m.struck = append(m.struck, m.world.meteors[before:]...)
+	} else {
+		m.done = true
+	}
+	if m.weapon.behaviour == MissileMine {
+		NewExplosion(m.world, m.position.X, m.position.Y, 30, expColor, 0.03)
+	}
+	return score
+}
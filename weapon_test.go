@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/paul63/vector2"
+)
+
+// A still meteor of size placed in the game's world at x, y
+func placeMeteor(g *Game, size int, x, y float64) *Meteor {
+	met := &Meteor{GameSprite: NewGameSprite(meteorSprites[size], vector2.Vector{X: x, Y: y}, vector2.Vector{}, 0),
+		world: g.world, size: size}
+	g.world.meteors = append(g.world.meteors, met)
+	return met
+}
+
+func TestDamageSplitsOnce(t *testing.T) {
+	g := NewGame(nil, DefaultOptions(), 1)
+	met := placeMeteor(g, 3, 500, 400)
+	miss := NewMissile(g.world, g.players[0], weapons[WeaponHoming], vector2.Vector{X: 500, Y: 400}, 0)
+	score := miss.HitMeteor(met)
+	if score != scores[3] + scores[2] {
+		t.Errorf("scored %d for knocking two sizes off a large meteor", score)
+	}
+	if met.size != 1 || len(g.world.meteors) != 2 || g.world.meteors[1].size != 1 {
+		t.Errorf("large meteor hit for 2 left size %d and %d meteors", met.size, len(g.world.meteors))
+	}
+	if len(g.world.explosions) != 1 {
+		t.Errorf("%d explosions from one hit", len(g.world.explosions))
+	}
+	// More damage than the meteor has sizes left destroys it
+	if score := miss.HitMeteor(g.world.meteors[1]); score != scores[1] + scores[0] || !g.world.meteors[1].done {
+		t.Errorf("small meteor hit for 2 scored %d done %t", score, g.world.meteors[1].done)
+	}
+}
+
+func TestLaserBeamHitsAcrossTheScreen(t *testing.T) {
+	g := NewGame(nil, DefaultOptions(), 1)
+	g.Start()
+	g.world.meteors = nil
+	p := g.players[0]
+	x, y := p.position.X, p.position.Y
+	// Up the screen from the ship in a line, the last near the top edge
+	inLine := [] *Meteor {placeMeteor(g, 3, x, y - 120), placeMeteor(g, 2, x, y - 220), placeMeteor(g, 3, x, 40)}
+	aside := placeMeteor(g, 3, x + 200, y - 200)
+	p.angle = 0
+	p.weapon = WeaponLaser
+	p.loaded = true
+	var fire InputState
+	fire.Set(ActionFire, true)
+	g.UpdatePlay([] InputState {fire})
+	for i, met := range inLine {
+		if !met.done && met.size == 3 - i % 2 {
+			t.Errorf("meteor %d in the beam's path was not hit", i)
+		}
+	}
+	if aside.size != 3 {
+		t.Error("meteor beside the beam was hit")
+	}
+	if len(g.world.missiles) != 1 || g.world.missiles[0].done {
+		t.Fatal("beam is not shown after it was fired")
+	}
+	// The beam stays on the screen for a while but only hits as it is fired
+	later := placeMeteor(g, 3, x, y - 320)
+	g.UpdatePlay([] InputState {{}})
+	if later.size != 3 {
+		t.Error("beam hit a meteor after the tick it was fired")
+	}
+}
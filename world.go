@@ -0,0 +1,175 @@
+// World struct and methods
+// for Asteroids written in Go using Ebitengine
+// The world owns every entity collection, the random source and the timers
+// so that more than one game can be simulated at once.
+
+package main
+
+import (
+	"math/rand/v2"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/paul63/vector2"
+)
+
+type World struct {
+	meteors		[] *Meteor
+	missiles	[] *Missile
+	explosions	[] *Explosion
+	saucers		[] *Saucer
+	saucerShots	[] *SaucerShot
+	pickups		[] *Pickup
+	players		[] *Player		// Players stay in the world for the whole game
+	stars		[] *Star
+	timers		*TimerManager
+	meteorGrid	*SpatialHash	// Meteors by position, rebuilt each tick
+	nearby		[] int			// Reused for spatial hash queries
+	nearMeteors	[] *Meteor
+	placedA		[] [] vector2.Vector	// Reused for shapes placed for collision checks
+	placedB		[] [] vector2.Vector
+	options		Options
+	meteorSpeed	float64		// Multiplies the speed of new meteors, set by the wave
+	timeScale	float64		// Speed everything but the player moves at, 1 is normal
+	seed		uint64
+	rng			*rand.Rand	// Used by everything that affects play
+	starRng		*rand.Rand	// Used by the background only so it does not change play
+}
+
+// Create a new empty world played with the options given
+// and its random source seeded by seed
+func NewWorld(options Options, seed uint64) *World {
+	w := &World{
+		options:	options,
+		meteorSpeed:	1,
+		timeScale:	1,
+		timers:		NewTimerManager(),
+		meteorGrid:	NewSpatialHash(HashCellSize, options.wrap),
+	}
+	w.SetSeed(seed)
+	return w
+}
+
+// Reseed the world's random sources so the same seed and input
+// always produce the same game
+func (w *World) SetSeed(seed uint64) {
+	w.seed = seed
+	w.rng = rand.New(rand.NewPCG(seed, 0))
+	w.starRng = rand.New(rand.NewPCG(seed, 1))
+}
+
+// Update timers and remove finished entities
+// (call once every frame while the game is in play before anything moves)
+func (w *World) Update() {
+	w.UpdateAllTimers()
+	w.ClearDoneExplosions()
+	w.ClearDoneMeteors()
+	w.ClearDoneMissiles()
+	w.ClearDoneSaucers()
+	w.ClearDoneSaucerShots()
+	w.ClearDonePickups()
+}
+
+// Move everything in play but the players and explosions
+// (call once every frame after the players have moved so a missile
+// fired this frame moves this frame)
+func (w *World) Move() {
+	w.UpdateAllMeteors()
+	w.UpdateAllMissiles()
+	w.UpdateAllSaucers()
+	w.UpdateAllSaucerShots()
+	w.UpdateAllPickups()
+}
+
+// Change the speed of everything but the player and its missiles,
+// timers run at the same speed so meteors and saucers also arrive more slowly
+func (w *World) SetTimeScale(scale float64) {
+	w.timeScale = scale
+	w.timers.SetScale(scale)
+}
+
+// Add every meteor to the spatial hash ready for collision checks
+// (call each tick after the meteors have moved)
+// Only meteors are added. There is never more than one saucer and two
+// players, and the missiles, saucer shots and pickups are only checked
+// against those, so checking every pair costs less than building a second
+// hash each tick (compare the BenchmarkSmallSets benchmarks in bench_test.go)
+func (w *World) BuildMeteorGrid() {
+	w.meteorGrid.Clear()
+	for i, m := range w.meteors {
+		w.meteorGrid.Insert(i, m.Bounds())
+	}
+}
+
+// Meteors that may collide with pos, in the order they were added to the world
+// The slice returned is only valid until the next call
+func (w *World) MeteorsNear(pos ScreenPos) [] *Meteor {
+	w.nearby = w.meteorGrid.Query(pos, w.nearby[:0])
+	// Same order as the meteors so the first hit is the same as checking them all
+	slices.Sort(w.nearby)
+	w.nearMeteors = w.nearMeteors[:0]
+	for _, i := range w.nearby {
+		w.nearMeteors = append(w.nearMeteors, w.meteors[i])
+	}
+	return w.nearMeteors
+}
+
+// Number of players still flying
+func (w *World) PlayersAlive() int {
+	alive := 0
+	for _, p := range w.players {
+		if p.alive {
+			alive++
+		}
+	}
+	return alive
+}
+
+// A player for meteors and saucers to head for, chosen at random from the
+// players still flying or from every player if none are
+func (w *World) TargetPlayer() *Player {
+	var targets [] *Player
+	for _, p := range w.players {
+		if p.alive {
+			targets = append(targets, p)
+		}
+	}
+	if len(targets) == 0 {
+		targets = w.players
+	}
+	if len(targets) == 1 {
+		return targets[0]
+	}
+	return targets[w.rng.IntN(len(targets))]
+}
+
+// Number of meteors still in play
+func (w *World) MeteorsLeft() int {
+	left := 0
+	for _, m := range w.meteors {
+		if !m.done {
+			left++
+		}
+	}
+	return left
+}
+
+// Draw all meteors, missiles, saucers, pickups and explosions
+func (w *World) Draw(screen *ebiten.Image) {
+	w.DrawAllPickups(screen)
+	w.DrawAllMeteors(screen)
+	w.DrawAllMissiles(screen)
+	w.DrawAllSaucers(screen)
+	w.DrawAllSaucerShots(screen)
+	w.DrawAllExplosions(screen)
+}
+
+// Remove all meteors, missiles, saucers, pickups and explosions ready for a new life or game
+func (w *World) Clear() {
+	w.ClearAllPickups()
+	w.ClearAllMeteors()
+	w.ClearAllMissiles()
+	w.ClearAllSaucers()
+	w.ClearAllSaucerShots()
+	w.ClearAllExplosions()
+}